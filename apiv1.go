@@ -0,0 +1,410 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"cineseer/components"
+	"cineseer/tmdb"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// maxAPIPage is the last page TMDB will serve for any paged endpoint
+const maxAPIPage = 500
+
+// apiV1 builds the /api/v1 JSON API. Image and page URLs in responses
+// include the base path, so clients can follow them as-is.
+type apiV1 struct {
+	client   *tmdb.Client
+	basePath string
+}
+
+// setupAPIv1 registers the JSON API under api/v1
+func setupAPIv1(api fiber.Router, client *tmdb.Client, basePath string) {
+	a := &apiV1{client: client, basePath: basePath}
+	v1 := api.Group("/v1", negotiateJSON)
+
+	v1.Get("/home", a.home)
+	v1.Get("/home/:section", a.homeSection)
+	v1.Get("/movies/:id", a.movie)
+	v1.Get("/movies/:id/images", a.images(apiTypeMovie))
+	v1.Get("/series/:id", a.series)
+	v1.Get("/series/:id/images", a.images(apiTypeSeries))
+	v1.Get("/series/:id/seasons/:season", a.season)
+	v1.Get("/search", a.search)
+
+	// Anything else under v1 gets a JSON 404 rather than the HTML one
+	v1.Use(func(c *fiber.Ctx) error {
+		return apiError(c, fiber.StatusNotFound, "not_found", "No such endpoint")
+	})
+}
+
+// negotiateJSON refuses clients that can't take JSON, the only
+// representation the v1 API has
+func negotiateJSON(c *fiber.Ctx) error {
+	if c.Accepts(fiber.MIMEApplicationJSON) == "" {
+		return apiError(c, fiber.StatusNotAcceptable, "not_acceptable", "The v1 API only serves application/json")
+	}
+	c.Vary(fiber.HeaderAccept)
+	return c.Next()
+}
+
+func apiOK(c *fiber.Ctx, data interface{}, pagination *Pagination) error {
+	return c.JSON(APIResponse{Data: data, Pagination: pagination})
+}
+
+func apiError(c *fiber.Ctx, status int, code, message string) error {
+	return c.Status(status).JSON(APIErrorResponse{Error: APIErrorBody{
+		Status:  status,
+		Code:    code,
+		Message: message,
+	}})
+}
+
+// apiUpstreamError reports a failed TMDB call, passing through TMDB's 404s
+func apiUpstreamError(c *fiber.Ctx, what string, err error) error {
+	var apiErr *tmdb.APIError
+	switch {
+	case errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound:
+		return apiError(c, fiber.StatusNotFound, "not_found", what+" not found")
+	case errors.Is(err, context.DeadlineExceeded):
+		return apiError(c, fiber.StatusGatewayTimeout, "upstream_timeout", "Timed out fetching "+what+" from TMDB")
+	}
+	log.Printf("Error fetching %s for API: %v", what, err)
+	return apiError(c, fiber.StatusBadGateway, "upstream_error", "Failed to fetch "+what+" from TMDB")
+}
+
+// paramID reads a positive integer path parameter
+func paramID(c *fiber.Ctx, name string) (int, error) {
+	id, err := c.ParamsInt(name)
+	if err != nil || id <= 0 {
+		return 0, fmt.Errorf("invalid %s", name)
+	}
+	return id, nil
+}
+
+func (a *apiV1) url(path string) string {
+	return a.basePath + path
+}
+
+// imageURL is the proxied URL for an image, or "" when TMDB has none
+func (a *apiV1) imageURL(kind string, id int, imgType, tmdbPath string) string {
+	if tmdbPath == "" {
+		return ""
+	}
+	return a.url(components.ImagePath(kind, id, imgType))
+}
+
+func (a *apiV1) mediaSummary(item tmdb.MediaContent) MediaSummary {
+	summary := MediaSummary{
+		ID:       item.ID,
+		Title:    item.Title,
+		Overview: item.Overview,
+		Rating:   item.VoteAverage,
+	}
+	if item.MediaType == tmdb.MediaTypeMovie || (item.MediaType == "" && isMovie(item)) {
+		summary.Type = apiTypeMovie
+		summary.ReleaseDate = item.ReleaseDate
+	} else {
+		summary.Type = apiTypeSeries
+		summary.Title = item.Name
+		summary.ReleaseDate = item.FirstAirDate
+	}
+	if summary.Title == "" {
+		summary.Title = item.Name + item.Title
+	}
+	summary.Year = yearOf(summary.ReleaseDate)
+	summary.PosterURL = a.imageURL(summary.Type, item.ID, "poster", item.PosterPath)
+	summary.BackdropURL = a.imageURL(summary.Type, item.ID, "backdrop", item.BackdropPath)
+	summary.URL = a.url(fmt.Sprintf("/%s/%d", summary.Type, item.ID))
+	return summary
+}
+
+func (a *apiV1) mediaSummaries(items []tmdb.MediaContent) []MediaSummary {
+	summaries := make([]MediaSummary, 0, len(items))
+	for _, item := range items {
+		summaries = append(summaries, a.mediaSummary(item))
+	}
+	return summaries
+}
+
+// pagination builds the metadata for a paged response, linking neighbouring
+// pages with the request's other query parameters kept
+func (a *apiV1) pagination(c *fiber.Ctx, page, totalPages, totalResults int) *Pagination {
+	p := &Pagination{Page: page, TotalPages: totalPages, TotalResults: totalResults}
+	link := func(page int) string {
+		args := fiber.AcquireArgs()
+		defer fiber.ReleaseArgs(args)
+		c.Request().URI().QueryArgs().CopyTo(args)
+		args.Set("page", strconv.Itoa(page))
+		return c.Path() + "?" + args.String()
+	}
+	if page < totalPages {
+		p.Next = link(page + 1)
+	}
+	if page > 1 {
+		p.Prev = link(page - 1)
+	}
+	return p
+}
+
+func (a *apiV1) homeSections(c *fiber.Ctx) ([]HomeSection, error) {
+	data, err := getHomePageData(c.Context(), a.client)
+	if err != nil {
+		return nil, err
+	}
+	sections := make([]HomeSection, 0, len(homeSections))
+	for _, section := range homeSections {
+		sections = append(sections, HomeSection{
+			ID:    section.key,
+			Title: strings.ToUpper(section.name[:1]) + section.name[1:],
+			Items: a.mediaSummaries(*section.field(data)),
+		})
+	}
+	return sections, nil
+}
+
+func (a *apiV1) home(c *fiber.Ctx) error {
+	sections, err := a.homeSections(c)
+	if err != nil {
+		return apiUpstreamError(c, "home page", err)
+	}
+	return apiOK(c, sections, nil)
+}
+
+func (a *apiV1) homeSection(c *fiber.Ctx) error {
+	sections, err := a.homeSections(c)
+	if err != nil {
+		return apiUpstreamError(c, "home page", err)
+	}
+	for _, section := range sections {
+		if section.ID == c.Params("section") {
+			return apiOK(c, section, nil)
+		}
+	}
+	return apiError(c, fiber.StatusNotFound, "not_found", "No such home section")
+}
+
+// details converts a movie's or series' details, with certifications for
+// the region given by ?region= (default US)
+func (a *apiV1) details(c *fiber.Ctx, content *tmdb.DetailedContent, apiType string) MediaDetails {
+	item := tmdb.MediaContent{
+		ID:           content.ID,
+		Name:         content.Name,
+		Title:        content.Title,
+		Overview:     content.Overview,
+		PosterPath:   content.PosterPath,
+		BackdropPath: content.BackdropPath,
+		VoteAverage:  content.VoteAverage,
+		ReleaseDate:  content.ReleaseDate,
+		FirstAirDate: content.FirstAirDate,
+		MediaType:    tmdb.MediaTypeTV,
+	}
+	region := strings.ToUpper(c.Query("region", "US"))
+	certification := content.ContentRatings.Rating(region)
+	if apiType == apiTypeMovie {
+		item.MediaType = tmdb.MediaTypeMovie
+		certification = content.ReleaseDates.Certification(region)
+	}
+
+	details := MediaDetails{
+		MediaSummary:  a.mediaSummary(item),
+		Tagline:       content.Tagline,
+		Status:        content.Status,
+		Runtime:       content.Runtime,
+		Genres:        make([]string, 0, len(content.Genres)),
+		Certification: certification,
+		SeasonCount:   content.NumberOfSeasons,
+		Cast:          make([]CreditDTO, 0, len(content.Credits.Cast)),
+		Crew:          make([]CreditDTO, 0, len(content.Credits.Crew)),
+	}
+	for _, genre := range content.Genres {
+		details.Genres = append(details.Genres, genre.Name)
+	}
+	for _, member := range content.Credits.Cast {
+		details.Cast = append(details.Cast, CreditDTO{
+			ID:   member.ID,
+			Name: member.Name,
+			Role: member.Role,
+			URL:  a.url(fmt.Sprintf("/person/%d", member.ID)),
+		})
+	}
+	for _, member := range content.Credits.Crew {
+		details.Crew = append(details.Crew, CreditDTO{
+			ID:   member.ID,
+			Name: member.Name,
+			Role: member.Job,
+			URL:  a.url(fmt.Sprintf("/person/%d", member.ID)),
+		})
+	}
+	if video := content.Videos.BestTrailer(uiLanguage(c)); video != nil {
+		details.Trailer = &TrailerDTO{Name: video.Name, EmbedURL: video.EmbedURL()}
+	}
+	if collection := content.BelongsToCollection; collection != nil {
+		details.Collection = &CollectionRef{
+			ID:   collection.ID,
+			Name: collection.Name,
+			URL:  a.url(fmt.Sprintf("/collection/%d", collection.ID)),
+		}
+	}
+	return details
+}
+
+func (a *apiV1) movie(c *fiber.Ctx) error {
+	id, err := paramID(c, "id")
+	if err != nil {
+		return apiError(c, fiber.StatusBadRequest, "invalid_request", "Invalid movie ID")
+	}
+	content, err := a.client.MovieDetails(c.Context(), id)
+	if err != nil {
+		return apiUpstreamError(c, "movie", err)
+	}
+	return apiOK(c, a.details(c, content, apiTypeMovie), nil)
+}
+
+func (a *apiV1) series(c *fiber.Ctx) error {
+	id, err := paramID(c, "id")
+	if err != nil {
+		return apiError(c, fiber.StatusBadRequest, "invalid_request", "Invalid series ID")
+	}
+	content, err := a.client.SeriesDetails(c.Context(), id)
+	if err != nil {
+		return apiUpstreamError(c, "series", err)
+	}
+	return apiOK(c, a.details(c, content, apiTypeSeries), nil)
+}
+
+func (a *apiV1) season(c *fiber.Ctx) error {
+	id, err := paramID(c, "id")
+	if err != nil {
+		return apiError(c, fiber.StatusBadRequest, "invalid_request", "Invalid series ID")
+	}
+	number, err := c.ParamsInt("season")
+	if err != nil || number < 0 {
+		return apiError(c, fiber.StatusBadRequest, "invalid_request", "Invalid season number")
+	}
+	season, err := a.client.SeasonDetails(c.Context(), id, number)
+	if err != nil {
+		return apiUpstreamError(c, "season", err)
+	}
+
+	dto := SeasonDTO{
+		SeriesID:     id,
+		SeasonNumber: number,
+		Name:         season.Name,
+		Overview:     season.Overview,
+		AirDate:      season.AirDate,
+		Episodes:     make([]EpisodeDTO, 0, len(season.Episodes)),
+	}
+	if season.PosterPath != "" {
+		dto.PosterURL = a.url(fmt.Sprintf("/api/image/tv/%d/season/%d/poster", id, number))
+	}
+	for _, ep := range season.Episodes {
+		episode := EpisodeDTO{
+			SeasonNumber:  number,
+			EpisodeNumber: ep.EpisodeNumber,
+			Name:          ep.Name,
+			Overview:      ep.Overview,
+			AirDate:       ep.AirDate,
+			Rating:        ep.VoteAverage,
+		}
+		if ep.StillPath != "" {
+			episode.StillURL = a.url(fmt.Sprintf("/api/image/tv/%d/season/%d/episode/%d/still", id, number, ep.EpisodeNumber))
+		}
+		dto.Episodes = append(dto.Episodes, episode)
+	}
+	return apiOK(c, dto, nil)
+}
+
+func (a *apiV1) search(c *fiber.Ctx) error {
+	query := strings.TrimSpace(c.Query("q"))
+	if query == "" {
+		return apiError(c, fiber.StatusBadRequest, "invalid_request", "Query parameter q is required")
+	}
+	mediaType := c.Query("type")
+	switch mediaType {
+	case "", tmdb.MediaTypeMovie, tmdb.MediaTypeTV, tmdb.MediaTypePerson:
+	case apiTypeSeries:
+		mediaType = tmdb.MediaTypeTV
+	default:
+		return apiError(c, fiber.StatusBadRequest, "invalid_request", "type must be movie, series or person")
+	}
+	page := c.QueryInt("page", 1)
+	if page < 1 || page > maxAPIPage {
+		return apiError(c, fiber.StatusBadRequest, "invalid_request", fmt.Sprintf("page must be between 1 and %d", maxAPIPage))
+	}
+
+	resp, err := a.client.Search(c.Context(), query, mediaType, page)
+	if err != nil {
+		return apiUpstreamError(c, "search results", err)
+	}
+
+	results := make([]SearchResult, 0, len(resp.Results))
+	for _, item := range resp.Results {
+		if item.MediaType != tmdb.MediaTypePerson {
+			summary := a.mediaSummary(item)
+			results = append(results, SearchResult{Type: summary.Type, Media: &summary})
+			continue
+		}
+		person := PersonSummary{
+			ID:         item.ID,
+			Type:       apiTypePerson,
+			Name:       item.Name,
+			Department: item.KnownForDepartment,
+			KnownFor:   make([]string, 0, len(item.KnownFor)),
+			ProfileURL: a.imageURL(apiTypePerson, item.ID, "profile", item.ProfilePath),
+			URL:        a.url(fmt.Sprintf("/person/%d", item.ID)),
+		}
+		for _, k := range item.KnownFor {
+			if k.Title != "" {
+				person.KnownFor = append(person.KnownFor, k.Title)
+			} else if k.Name != "" {
+				person.KnownFor = append(person.KnownFor, k.Name)
+			}
+		}
+		results = append(results, SearchResult{Type: apiTypePerson, Person: &person})
+	}
+	return apiOK(c, results, a.pagination(c, resp.Page, min(resp.TotalPages, maxAPIPage), resp.TotalResults))
+}
+
+// images lists the poster and backdrop of a movie or series with the widths
+// the image proxy serves them at
+func (a *apiV1) images(apiType string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		id, err := paramID(c, "id")
+		if err != nil {
+			return apiError(c, fiber.StatusBadRequest, "invalid_request", "Invalid "+apiType+" ID")
+		}
+		var content *tmdb.DetailedContent
+		if apiType == apiTypeMovie {
+			content, err = a.client.MovieDetails(c.Context(), id)
+		} else {
+			content, err = a.client.SeriesDetails(c.Context(), id)
+		}
+		if err != nil {
+			return apiUpstreamError(c, apiType, err)
+		}
+
+		images := make([]ImageDTO, 0, 2)
+		add := func(imgType, tmdbPath string, widths []int) {
+			if tmdbPath == "" {
+				return
+			}
+			path := a.url(components.ImagePath(apiType, id, imgType))
+			image := ImageDTO{Type: imgType, URL: path, Sizes: make([]ImageSizeDTO, 0, len(widths))}
+			for _, w := range widths {
+				image.Sizes = append(image.Sizes, ImageSizeDTO{Width: w, URL: fmt.Sprintf("%s?w=%d", path, w)})
+			}
+			images = append(images, image)
+		}
+		add("poster", content.PosterPath, imageBuckets["poster"])
+		add("backdrop", content.BackdropPath, imageBuckets["backdrop"])
+		return apiOK(c, images, nil)
+	}
+}
@@ -0,0 +1,171 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// apiEnvelope decodes either kind of v1 response
+type apiEnvelope struct {
+	Data       json.RawMessage `json:"data"`
+	Pagination *Pagination     `json:"pagination"`
+	Error      *APIErrorBody   `json:"error"`
+}
+
+func newAPIv1TestApp(t *testing.T) *fiber.App {
+	t.Helper()
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/search/movie":
+			page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+			totalPages := 3
+			if r.URL.Query().Get("query") == "everything" {
+				totalPages = 1000
+			}
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"page":          page,
+				"total_pages":   totalPages,
+				"total_results": totalPages * 20,
+				"results":       []map[string]interface{}{{"id": 348, "title": "Alien", "release_date": "1979-05-25"}},
+			})
+		case "/movie/348":
+			io.WriteString(w, `{"id": 348, "title": "Alien", "release_date": "1979-05-25"}`)
+		case "/movie/404":
+			w.WriteHeader(http.StatusNotFound)
+			io.WriteString(w, `{"status_code": 34, "status_message": "The resource you requested could not be found."}`)
+		default:
+			w.WriteHeader(http.StatusInternalServerError)
+			io.WriteString(w, `{"status_code": 11, "status_message": "Internal error."}`)
+		}
+	})
+	app := fiber.New()
+	setupAPIv1(app.Group("/api"), client, "")
+	return app
+}
+
+func apiV1Request(t *testing.T, app *fiber.App, path, accept string) (*http.Response, apiEnvelope) {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	if accept != "" {
+		req.Header.Set(fiber.HeaderAccept, accept)
+	}
+	resp, err := app.Test(req, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	var envelope apiEnvelope
+	if err := json.Unmarshal(body, &envelope); err != nil {
+		t.Fatalf("GET %s: body is not JSON: %s", path, body)
+	}
+	if strings.Contains(string(body), "api_key") {
+		t.Errorf("GET %s: body leaks request details: %s", path, body)
+	}
+	return resp, envelope
+}
+
+func TestAPIv1Errors(t *testing.T) {
+	app := newAPIv1TestApp(t)
+	tests := []struct {
+		name   string
+		path   string
+		accept string
+		status int
+		code   string
+	}{
+		{"unknown endpoint", "/api/v1/nothing", "", http.StatusNotFound, "not_found"},
+		{"invalid ID", "/api/v1/movies/abc", "", http.StatusBadRequest, "invalid_request"},
+		{"missing query", "/api/v1/search", "", http.StatusBadRequest, "invalid_request"},
+		{"page past TMDB's last", "/api/v1/search?q=alien&page=501", "", http.StatusBadRequest, "invalid_request"},
+		{"HTML only", "/api/v1/movies/348", "text/html", http.StatusNotAcceptable, "not_acceptable"},
+		{"JSON ruled out", "/api/v1/movies/348", "text/html, application/json;q=0", http.StatusNotAcceptable, "not_acceptable"},
+		{"not found upstream", "/api/v1/movies/404", "", http.StatusNotFound, "not_found"},
+		{"failed upstream", "/api/v1/movies/500", "", http.StatusBadGateway, "upstream_error"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, envelope := apiV1Request(t, app, tt.path, tt.accept)
+			if resp.StatusCode != tt.status {
+				t.Errorf("status %d, want %d", resp.StatusCode, tt.status)
+			}
+			if ct := resp.Header.Get(fiber.HeaderContentType); !strings.HasPrefix(ct, fiber.MIMEApplicationJSON) {
+				t.Errorf("Content-Type %q, want JSON", ct)
+			}
+			if envelope.Error == nil {
+				t.Fatal("no error in the envelope")
+			}
+			if envelope.Error.Status != tt.status || envelope.Error.Code != tt.code || envelope.Error.Message == "" {
+				t.Errorf("error = %+v, want status %d and code %s with a message", envelope.Error, tt.status, tt.code)
+			}
+			if envelope.Data != nil || envelope.Pagination != nil {
+				t.Errorf("error response also has data or pagination")
+			}
+		})
+	}
+}
+
+func TestAPIv1NegotiatesJSON(t *testing.T) {
+	app := newAPIv1TestApp(t)
+	for _, accept := range []string{"", "*/*", "application/json", "text/html, application/json;q=0.5"} {
+		resp, envelope := apiV1Request(t, app, "/api/v1/movies/348", accept)
+		if resp.StatusCode != http.StatusOK {
+			t.Errorf("Accept %q: status %d, want 200", accept, resp.StatusCode)
+			continue
+		}
+		if vary := resp.Header.Get(fiber.HeaderVary); !strings.Contains(vary, fiber.HeaderAccept) {
+			t.Errorf("Accept %q: Vary %q, want Accept", accept, vary)
+		}
+		var movie MediaDetails
+		if err := json.Unmarshal(envelope.Data, &movie); err != nil || movie.ID != 348 || movie.Title != "Alien" {
+			t.Errorf("Accept %q: data = %s", accept, envelope.Data)
+		}
+		if envelope.Error != nil || envelope.Pagination != nil {
+			t.Errorf("Accept %q: unpaged response has %+v, %+v", accept, envelope.Error, envelope.Pagination)
+		}
+	}
+}
+
+func TestAPIv1Pagination(t *testing.T) {
+	app := newAPIv1TestApp(t)
+	tests := []struct {
+		name       string
+		path       string
+		page       int
+		totalPages int
+		next       string
+		prev       string
+	}{
+		{"first page", "/api/v1/search?q=alien&type=movie", 1, 3, "/api/v1/search?q=alien&type=movie&page=2", ""},
+		{"middle page", "/api/v1/search?q=alien&page=2&type=movie", 2, 3, "/api/v1/search?q=alien&page=3&type=movie", "/api/v1/search?q=alien&page=1&type=movie"},
+		{"last page", "/api/v1/search?q=alien&type=movie&page=3", 3, 3, "", "/api/v1/search?q=alien&type=movie&page=2"},
+		{"TMDB's last page", "/api/v1/search?q=everything&type=movie&page=500", 500, 500, "", "/api/v1/search?q=everything&type=movie&page=499"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, envelope := apiV1Request(t, app, tt.path, "")
+			if resp.StatusCode != http.StatusOK {
+				t.Fatalf("status %d, want 200", resp.StatusCode)
+			}
+			p := envelope.Pagination
+			if p == nil {
+				t.Fatal("no pagination")
+			}
+			if p.Page != tt.page || p.TotalPages != tt.totalPages {
+				t.Errorf("page %d of %d, want %d of %d", p.Page, p.TotalPages, tt.page, tt.totalPages)
+			}
+			if p.Next != tt.next {
+				t.Errorf("next = %q, want %q", p.Next, tt.next)
+			}
+			if p.Prev != tt.prev {
+				t.Errorf("prev = %q, want %q", p.Prev, tt.prev)
+			}
+		})
+	}
+}
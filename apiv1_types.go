@@ -0,0 +1,146 @@
+package main
+
+// DTOs for the /api/v1 JSON API. These are the public contract for other
+// tools and deliberately don't mirror TMDB's structs: fields are only ever
+// added, never renamed or removed, within v1.
+
+// Media types used in the v1 API, matching the site's page URLs
+const (
+	apiTypeMovie  = "movie"
+	apiTypeSeries = "series"
+	apiTypePerson = "person"
+)
+
+// APIResponse wraps every successful v1 response
+type APIResponse struct {
+	Data       interface{} `json:"data"`
+	Pagination *Pagination `json:"pagination,omitempty"`
+}
+
+// APIErrorResponse wraps every failed v1 response
+type APIErrorResponse struct {
+	Error APIErrorBody `json:"error"`
+}
+
+type APIErrorBody struct {
+	Status  int    `json:"status"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// Pagination describes where a page sits in a paged result. Next and Prev
+// are API URLs, empty at either end.
+type Pagination struct {
+	Page         int    `json:"page"`
+	TotalPages   int    `json:"total_pages"`
+	TotalResults int    `json:"total_results"`
+	Next         string `json:"next,omitempty"`
+	Prev         string `json:"prev,omitempty"`
+}
+
+// MediaSummary is a movie or series as shown in lists
+type MediaSummary struct {
+	ID          int     `json:"id"`
+	Type        string  `json:"type"`
+	Title       string  `json:"title"`
+	Overview    string  `json:"overview"`
+	ReleaseDate string  `json:"release_date,omitempty"`
+	Year        string  `json:"year,omitempty"`
+	Rating      float64 `json:"rating"`
+	PosterURL   string  `json:"poster_url,omitempty"`
+	BackdropURL string  `json:"backdrop_url,omitempty"`
+	URL         string  `json:"url"`
+}
+
+// PersonSummary is a person as shown in search results
+type PersonSummary struct {
+	ID         int      `json:"id"`
+	Type       string   `json:"type"`
+	Name       string   `json:"name"`
+	Department string   `json:"department,omitempty"`
+	KnownFor   []string `json:"known_for"`
+	ProfileURL string   `json:"profile_url,omitempty"`
+	URL        string   `json:"url"`
+}
+
+// HomeSection is one row of the home page
+type HomeSection struct {
+	ID    string         `json:"id"`
+	Title string         `json:"title"`
+	Items []MediaSummary `json:"items"`
+}
+
+// MediaDetails is the full record of a movie or series
+type MediaDetails struct {
+	MediaSummary
+	Tagline       string         `json:"tagline,omitempty"`
+	Status        string         `json:"status,omitempty"`
+	Runtime       int            `json:"runtime,omitempty"`
+	Genres        []string       `json:"genres"`
+	Certification string         `json:"certification,omitempty"`
+	SeasonCount   int            `json:"season_count,omitempty"`
+	Cast          []CreditDTO    `json:"cast"`
+	Crew          []CreditDTO    `json:"crew"`
+	Trailer       *TrailerDTO    `json:"trailer,omitempty"`
+	Collection    *CollectionRef `json:"collection,omitempty"`
+}
+
+// CreditDTO is a cast role (character) or crew job
+type CreditDTO struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+	Role string `json:"role"`
+	URL  string `json:"url"`
+}
+
+type TrailerDTO struct {
+	Name     string `json:"name"`
+	EmbedURL string `json:"embed_url"`
+}
+
+type CollectionRef struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+	URL  string `json:"url"`
+}
+
+// SeasonDTO is one season of a series with its episodes
+type SeasonDTO struct {
+	SeriesID     int          `json:"series_id"`
+	SeasonNumber int          `json:"season_number"`
+	Name         string       `json:"name"`
+	Overview     string       `json:"overview"`
+	AirDate      string       `json:"air_date,omitempty"`
+	PosterURL    string       `json:"poster_url,omitempty"`
+	Episodes     []EpisodeDTO `json:"episodes"`
+}
+
+type EpisodeDTO struct {
+	SeasonNumber  int     `json:"season_number"`
+	EpisodeNumber int     `json:"episode_number"`
+	Name          string  `json:"name"`
+	Overview      string  `json:"overview"`
+	AirDate       string  `json:"air_date,omitempty"`
+	Rating        float64 `json:"rating"`
+	StillURL      string  `json:"still_url,omitempty"`
+}
+
+// SearchResult is one search hit; exactly one of Media and Person is set
+type SearchResult struct {
+	Type   string         `json:"type"`
+	Media  *MediaSummary  `json:"media,omitempty"`
+	Person *PersonSummary `json:"person,omitempty"`
+}
+
+// ImageDTO lists a proxied image at its original size and the widths the
+// image proxy is tuned for. Every URL negotiates WebP/AVIF from Accept.
+type ImageDTO struct {
+	Type  string         `json:"type"`
+	URL   string         `json:"url"`
+	Sizes []ImageSizeDTO `json:"sizes"`
+}
+
+type ImageSizeDTO struct {
+	Width int    `json:"width"`
+	URL   string `json:"url"`
+}
@@ -0,0 +1,438 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/url"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+	"unicode"
+
+	"cineseer/components"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/limiter"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Accounts and sessions, nil when the server runs without a database
+var userStore UserStore
+
+const (
+	sessionCookieName = "cineseer_session"
+	defaultSessionTTL = 30 * 24 * time.Hour
+
+	// bcrypt ignores anything past 72 bytes, so longer passwords are refused
+	// rather than silently truncated
+	minPasswordLength = 8
+	maxPasswordLength = 72
+)
+
+var usernamePattern = regexp.MustCompile(`^[A-Za-z0-9_.-]{3,32}$`)
+
+// dummyPasswordHash stands in for accounts without a password when signing
+// in, at the same cost as real hashes
+var dummyPasswordHash, _ = bcrypt.GenerateFromPassword([]byte("cineseer-no-password"), bcrypt.DefaultCost)
+
+// authConfig is read from the environment by loadAuthConfig
+type authConfig struct {
+	SessionTTL    time.Duration
+	SecureCookies bool // always mark the cookie Secure, for TLS terminated by a proxy
+	AllowSignup   bool // let anyone create an account, not only the first user
+
+	// Trust ProxyHeader as the signed-in username on requests from
+	// TrustedProxies, for Authelia, oauth2-proxy and the like
+	ProxyHeader    string
+	TrustedProxies []*net.IPNet
+}
+
+var auth = authConfig{SessionTTL: defaultSessionTTL}
+
+// loadAuthConfig reads SESSION_TTL, SESSION_COOKIE_SECURE, ALLOW_SIGNUP,
+// AUTH_PROXY_HEADER and AUTH_TRUSTED_PROXIES
+func loadAuthConfig() (authConfig, error) {
+	config := authConfig{SessionTTL: defaultSessionTTL}
+	if ttl := os.Getenv("SESSION_TTL"); ttl != "" {
+		d, err := time.ParseDuration(ttl)
+		if err != nil || d <= 0 {
+			return config, fmt.Errorf("invalid SESSION_TTL %q", ttl)
+		}
+		config.SessionTTL = d
+	}
+	config.SecureCookies = os.Getenv("SESSION_COOKIE_SECURE") == "true"
+	config.AllowSignup = os.Getenv("ALLOW_SIGNUP") == "true"
+
+	config.ProxyHeader = strings.TrimSpace(os.Getenv("AUTH_PROXY_HEADER"))
+	proxies := os.Getenv("AUTH_TRUSTED_PROXIES")
+	if proxies == "" {
+		proxies = "127.0.0.1/32,::1/128"
+	}
+	for _, cidr := range strings.Split(proxies, ",") {
+		cidr = strings.TrimSpace(cidr)
+		if cidr == "" {
+			continue
+		}
+		_, network, err := net.ParseCIDR(cidr)
+		if err != nil {
+			return config, fmt.Errorf("invalid AUTH_TRUSTED_PROXIES entry %q", cidr)
+		}
+		config.TrustedProxies = append(config.TrustedProxies, network)
+	}
+	return config, nil
+}
+
+// trustedProxy reports whether a request came straight from one of the
+// proxies allowed to set the username header
+func (a authConfig) trustedProxy(c *fiber.Ctx) bool {
+	return a.trusts(c.Context().RemoteIP())
+}
+
+func (a authConfig) trusts(ip net.IP) bool {
+	for _, network := range a.TrustedProxies {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// clientIP is the address of the visitor behind a request. Requests from
+// TrustedProxies are traced back through X-Forwarded-For to the last address
+// no trusted proxy vouches for, so visitors behind a proxy aren't all one
+// client.
+func (a authConfig) clientIP(c *fiber.Ctx) string {
+	remote := c.Context().RemoteIP()
+	if !a.trusts(remote) {
+		return remote.String()
+	}
+	forwarded := strings.Split(c.Get(fiber.HeaderXForwardedFor), ",")
+	for i := len(forwarded) - 1; i >= 0; i-- {
+		ip := net.ParseIP(strings.TrimSpace(forwarded[i]))
+		if ip == nil {
+			break
+		}
+		if !a.trusts(ip) {
+			return ip.String()
+		}
+	}
+	return remote.String()
+}
+
+// hashSessionToken is what the store keeps in place of a session token
+func hashSessionToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+func newSessionToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+type userKey struct{}
+
+// currentUser returns the signed-in user of a request, or nil
+func currentUser(c *fiber.Ctx) *User {
+	user, _ := c.Locals(userKey{}).(*User)
+	return user
+}
+
+// viewer describes the signed-in user to components
+func viewer(c *fiber.Ctx) components.Viewer {
+	v := components.Viewer{Accounts: userStore != nil}
+	if user := currentUser(c); user != nil {
+		v.Username = user.Username
+		v.Proxy = c.Locals(proxyUserKey{}) != nil
+
+		// Looked up once per request
+		statuses, ok := c.Locals(watchlistKey{}).(map[string]string)
+		if !ok {
+			statuses = watchStatuses(c.Context(), user.ID)
+			c.Locals(watchlistKey{}, statuses)
+		}
+		v.Watchlist = statuses
+	}
+	return v
+}
+
+type proxyUserKey struct{}
+
+type watchlistKey struct{}
+
+// authenticate resolves the user of each request, from the proxy header when
+// one is configured and trusted, otherwise from the session cookie
+func authenticate(c *fiber.Ctx) error {
+	if auth.ProxyHeader != "" && auth.trustedProxy(c) {
+		if username := strings.TrimSpace(c.Get(auth.ProxyHeader)); username != "" {
+			user, err := proxyUser(c.Context(), username)
+			if err != nil {
+				log.Printf("Error resolving proxy user %q: %v", username, err)
+				return c.Status(500).SendString("Error signing in")
+			}
+			c.Locals(userKey{}, user)
+			c.Locals(proxyUserKey{}, true)
+			return c.Next()
+		}
+	}
+
+	if token := c.Cookies(sessionCookieName); token != "" {
+		user, err := userStore.SessionUser(c.Context(), hashSessionToken(token), time.Now())
+		switch {
+		case err == nil:
+			c.Locals(userKey{}, user)
+		case errors.Is(err, ErrUserNotFound):
+			// Expired or signed out elsewhere
+			clearSessionCookie(c)
+		default:
+			log.Printf("Error looking up session: %v", err)
+		}
+	}
+	return c.Next()
+}
+
+// proxyUser returns the account of a username asserted by the proxy,
+// creating one without a password on first sight
+func proxyUser(ctx context.Context, username string) (*User, error) {
+	user, err := userStore.UserByName(ctx, username)
+	if errors.Is(err, ErrUserNotFound) {
+		user, err = userStore.CreateUser(ctx, username, "")
+		if errors.Is(err, ErrUserExists) {
+			// Created by a concurrent request
+			user, err = userStore.UserByName(ctx, username)
+		} else if err == nil {
+			log.Printf("Created account for proxy user %s", username)
+		}
+	}
+	return user, err
+}
+
+// startSession signs a user in by issuing a session cookie
+func startSession(c *fiber.Ctx, user *User) error {
+	token, err := newSessionToken()
+	if err != nil {
+		return err
+	}
+	expires := time.Now().Add(auth.SessionTTL)
+	if err := userStore.CreateSession(c.Context(), hashSessionToken(token), user.ID, expires); err != nil {
+		return err
+	}
+	c.Cookie(&fiber.Cookie{
+		Name:     sessionCookieName,
+		Value:    token,
+		Path:     cookiePath(),
+		Expires:  expires,
+		HTTPOnly: true,
+		Secure:   auth.SecureCookies || c.Protocol() == "https",
+		SameSite: fiber.CookieSameSiteLaxMode,
+	})
+	return nil
+}
+
+func clearSessionCookie(c *fiber.Ctx) {
+	c.Cookie(&fiber.Cookie{
+		Name:     sessionCookieName,
+		Path:     cookiePath(),
+		Expires:  time.Unix(0, 0),
+		MaxAge:   -1,
+		HTTPOnly: true,
+		SameSite: fiber.CookieSameSiteLaxMode,
+	})
+}
+
+// Set by setupAuth so cookies are scoped to BASE_PATH
+var authBasePath string
+
+func cookiePath() string {
+	if authBasePath == "" {
+		return "/"
+	}
+	return authBasePath
+}
+
+// signupOpen reports whether new local accounts may be created: always for
+// the first account, afterwards only with ALLOW_SIGNUP
+func signupOpen(ctx context.Context) (bool, error) {
+	if auth.AllowSignup {
+		return true, nil
+	}
+	n, err := userStore.CountUsers(ctx)
+	return n == 0, err
+}
+
+// safeRedirect keeps the post-login redirect on this site. Browsers read
+// backslashes as slashes and drop tabs and newlines, so "/\evil.example" or
+// "/<tab>/evil.example" would leave it.
+func safeRedirect(basePath, next string) string {
+	u, err := url.Parse(next)
+	if err != nil || u.Scheme != "" || u.Host != "" ||
+		!strings.HasPrefix(next, "/") || strings.HasPrefix(next, "//") ||
+		strings.Contains(next, "\\") || strings.ContainsFunc(next, unicode.IsControl) {
+		return basePath + "/"
+	}
+	return next
+}
+
+// signupProblem explains what is wrong with a new account's details, if
+// anything
+func signupProblem(username, password string) string {
+	if !usernamePattern.MatchString(username) {
+		return "Usernames are 3 to 32 letters, digits, dots, dashes or underscores"
+	}
+	if len(password) < minPasswordLength || len(password) > maxPasswordLength {
+		return fmt.Sprintf("Passwords are %d to %d characters", minPasswordLength, maxPasswordLength)
+	}
+	return ""
+}
+
+// setupAuth resolves the user of every request and serves the sign in, sign
+// up and sign out routes. It does nothing without a user store.
+func setupAuth(app *fiber.App, basePath string) {
+	if userStore == nil {
+		return
+	}
+	authBasePath = basePath
+	app.Use(authenticate)
+
+	loginProps := func(c *fiber.Ctx, signup bool) (components.AuthProps, error) {
+		open, err := signupOpen(c.Context())
+		return components.AuthProps{
+			Signup:     signup,
+			SignupOpen: open,
+			Next:       safeRedirect(basePath, c.Query("next", c.FormValue("next"))),
+			Username:   c.FormValue("username"),
+		}, err
+	}
+
+	showForm := func(signup bool) fiber.Handler {
+		return func(c *fiber.Ctx) error {
+			props, err := loginProps(c, signup)
+			if err != nil {
+				return c.Status(500).SendString(err.Error())
+			}
+			if currentUser(c) != nil {
+				return c.Redirect(props.Next)
+			}
+			if signup && !props.SignupOpen {
+				return c.Status(403).SendString("Sign up is closed")
+			}
+			return render(c, basePath, components.AuthPage(props))
+		}
+	}
+	app.Get(basePath+"/login", showForm(false))
+	app.Get(basePath+"/signup", showForm(true))
+
+	// Slow down password guessing, per visitor and account so that one
+	// client can't lock everyone else out
+	app.Post(basePath+"/login", limiter.New(limiter.Config{
+		Max:        10,
+		Expiration: time.Minute,
+		KeyGenerator: func(c *fiber.Ctx) string {
+			return auth.clientIP(c) + " " + strings.ToLower(strings.TrimSpace(c.FormValue("username")))
+		},
+		LimitReached: func(c *fiber.Ctx) error {
+			return c.Status(429).SendString("Too many sign in attempts, try again in a minute")
+		},
+	}), func(c *fiber.Ctx) error {
+		props, err := loginProps(c, false)
+		if err != nil {
+			return c.Status(500).SendString(err.Error())
+		}
+		username := strings.TrimSpace(c.FormValue("username"))
+		user, err := userStore.UserByName(c.Context(), username)
+		if err != nil && !errors.Is(err, ErrUserNotFound) {
+			return c.Status(500).SendString(err.Error())
+		}
+		// Unknown users and proxy-only accounts have no password to match,
+		// but are checked against a dummy hash so they take as long to turn
+		// away and don't give away which usernames exist
+		hash := dummyPasswordHash
+		if user != nil && user.PasswordHash != "" {
+			hash = []byte(user.PasswordHash)
+		}
+		matched := bcrypt.CompareHashAndPassword(hash, []byte(c.FormValue("password"))) == nil
+		if user == nil || user.PasswordHash == "" || !matched {
+			log.Printf("Failed sign in for %q from %s", username, auth.clientIP(c))
+			props.Error = "Wrong username or password"
+			c.Status(401)
+			return render(c, basePath, components.AuthPage(props))
+		}
+		if err := startSession(c, user); err != nil {
+			return c.Status(500).SendString(err.Error())
+		}
+		log.Printf("User %s signed in from %s", user.Username, auth.clientIP(c))
+		return c.Redirect(props.Next, 303)
+	})
+
+	app.Post(basePath+"/signup", func(c *fiber.Ctx) error {
+		props, err := loginProps(c, true)
+		if err != nil {
+			return c.Status(500).SendString(err.Error())
+		}
+		if !props.SignupOpen {
+			return c.Status(403).SendString("Sign up is closed")
+		}
+		username := strings.TrimSpace(c.FormValue("username"))
+		password := c.FormValue("password")
+		if problem := signupProblem(username, password); problem != "" {
+			props.Error = problem
+			c.Status(400)
+			return render(c, basePath, components.AuthPage(props))
+		}
+		hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+		if err != nil {
+			return c.Status(500).SendString(err.Error())
+		}
+		user, err := userStore.CreateUser(c.Context(), username, string(hash))
+		if errors.Is(err, ErrUserExists) {
+			props.Error = "That username is taken"
+			c.Status(409)
+			return render(c, basePath, components.AuthPage(props))
+		}
+		if err != nil {
+			return c.Status(500).SendString(err.Error())
+		}
+		log.Printf("Created account %s from %s", user.Username, c.IP())
+		if err := startSession(c, user); err != nil {
+			return c.Status(500).SendString(err.Error())
+		}
+		return c.Redirect(props.Next, 303)
+	})
+
+	app.Post(basePath+"/logout", func(c *fiber.Ctx) error {
+		if token := c.Cookies(sessionCookieName); token != "" {
+			if err := userStore.DeleteSession(c.Context(), hashSessionToken(token)); err != nil {
+				log.Printf("Error deleting session: %v", err)
+			}
+		}
+		clearSessionCookie(c)
+		return c.Redirect(basePath+"/", 303)
+	})
+}
+
+// pruneSessions deletes expired sessions every interval until ctx is done
+func pruneSessions(ctx context.Context, store UserStore, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := store.DeleteExpiredSessions(ctx, time.Now()); err != nil {
+				log.Printf("Error pruning expired sessions: %v", err)
+			}
+		}
+	}
+}
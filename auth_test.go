@@ -0,0 +1,240 @@
+package main
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// useAuthConfig replaces the auth settings for a test. fiber's app.Test
+// connects from 0.0.0.0, so trustedProxies should include it or not.
+func useAuthConfig(t *testing.T, proxyHeader string, trustedProxies ...string) {
+	t.Helper()
+	config := authConfig{SessionTTL: defaultSessionTTL, ProxyHeader: proxyHeader}
+	for _, cidr := range trustedProxies {
+		_, network, err := net.ParseCIDR(cidr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		config.TrustedProxies = append(config.TrustedProxies, network)
+	}
+	previous := auth
+	auth = config
+	t.Cleanup(func() { auth = previous })
+}
+
+// newAuthTestApp serves the auth routes from a fresh database, plus /whoami
+// naming the signed-in user and /ip naming the client
+func newAuthTestApp(t *testing.T) (*fiber.App, *sqliteStore) {
+	t.Helper()
+	store := useTestStore(t)
+	app := fiber.New()
+	setupAuth(app, "")
+	app.Get("/whoami", func(c *fiber.Ctx) error {
+		if user := currentUser(c); user != nil {
+			return c.SendString(user.Username)
+		}
+		return c.SendString("")
+	})
+	app.Get("/ip", func(c *fiber.Ctx) error {
+		return c.SendString(auth.clientIP(c))
+	})
+	return app, store
+}
+
+func authRequest(t *testing.T, app *fiber.App, req *http.Request) (int, string) {
+	t.Helper()
+	resp, err := app.Test(req, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resp.StatusCode, string(body)
+}
+
+func TestSafeRedirect(t *testing.T) {
+	tests := []struct {
+		next string
+		want string
+	}{
+		{"", "/app/"},
+		{"/app/watchlist", "/app/watchlist"},
+		{"/app/search?q=alien&type=movie", "/app/search?q=alien&type=movie"},
+		{"/movie/1#cast", "/movie/1#cast"},
+		{"watchlist", "/app/"},
+		{"https://evil.example/", "/app/"},
+		{"javascript:alert(1)", "/app/"},
+		{"//evil.example", "/app/"},
+		{"/\\evil.example", "/app/"},
+		{"\\\\evil.example", "/app/"},
+		{"/\t/evil.example", "/app/"},
+		{"/\n/evil.example", "/app/"},
+		{"/\r\n/evil.example", "/app/"},
+		{"/\x00/evil.example", "/app/"},
+		{"/\u0085/evil.example", "/app/"},
+	}
+	for _, tt := range tests {
+		if got := safeRedirect("/app", tt.next); got != tt.want {
+			t.Errorf("safeRedirect(%q) = %q, want %q", tt.next, got, tt.want)
+		}
+	}
+}
+
+func TestDummyPasswordHashCostsTheSame(t *testing.T) {
+	cost, err := bcrypt.Cost(dummyPasswordHash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cost != bcrypt.DefaultCost {
+		t.Errorf("dummy hash cost = %d, want %d like real passwords", cost, bcrypt.DefaultCost)
+	}
+}
+
+func TestClientIP(t *testing.T) {
+	tests := []struct {
+		name      string
+		trusted   []string
+		forwarded string
+		want      string
+	}{
+		{"direct", nil, "", "0.0.0.0"},
+		{"forwarded header from an untrusted peer ignored", nil, "203.0.113.7", "0.0.0.0"},
+		{"trusted proxy", []string{"0.0.0.0/32"}, "203.0.113.7", "203.0.113.7"},
+		{"spoofed entries left of the proxy's ignored", []string{"0.0.0.0/32"}, "198.51.100.1, 203.0.113.7", "203.0.113.7"},
+		{"chain of trusted proxies", []string{"0.0.0.0/32", "10.0.0.0/8"}, "203.0.113.7, 10.0.0.2", "203.0.113.7"},
+		{"trusted proxy without a header", []string{"0.0.0.0/32"}, "", "0.0.0.0"},
+		{"garbage", []string{"0.0.0.0/32"}, "not-an-ip", "0.0.0.0"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			useAuthConfig(t, "", tt.trusted...)
+			app, _ := newAuthTestApp(t)
+			req := httptest.NewRequest(http.MethodGet, "/ip", nil)
+			if tt.forwarded != "" {
+				req.Header.Set("X-Forwarded-For", tt.forwarded)
+			}
+			if _, got := authRequest(t, app, req); got != tt.want {
+				t.Errorf("clientIP = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoginRateLimitIsPerClientAndUsername(t *testing.T) {
+	useAuthConfig(t, "", "0.0.0.0/32")
+	app, store := newAuthTestApp(t)
+	hash, err := bcrypt.GenerateFromPassword([]byte("correct horse"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.CreateUser(context.Background(), "bob", string(hash)); err != nil {
+		t.Fatal(err)
+	}
+
+	login := func(client, username string) int {
+		form := url.Values{"username": {username}, "password": {"wrong"}}
+		req := httptest.NewRequest(http.MethodPost, "/login", strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		req.Header.Set("X-Forwarded-For", client)
+		status, _ := authRequest(t, app, req)
+		return status
+	}
+	for i := 0; i < 10; i++ {
+		if status := login("203.0.113.1", "bob"); status != http.StatusUnauthorized {
+			t.Fatalf("attempt %d: status %d, want 401", i+1, status)
+		}
+	}
+	if status := login("203.0.113.1", "Bob"); status != http.StatusTooManyRequests {
+		t.Errorf("11th attempt: status %d, want 429", status)
+	}
+	if status := login("203.0.113.2", "bob"); status != http.StatusUnauthorized {
+		t.Errorf("another client behind the proxy: status %d, want 401", status)
+	}
+	if status := login("203.0.113.1", "alice"); status != http.StatusUnauthorized {
+		t.Errorf("same client, another username: status %d, want 401", status)
+	}
+}
+
+func TestAuthenticateProxyHeader(t *testing.T) {
+	tests := []struct {
+		name    string
+		header  string
+		trusted []string
+		want    string
+	}{
+		{"trusted proxy", "Remote-User", []string{"0.0.0.0/32"}, "carol"},
+		{"untrusted peer", "Remote-User", []string{"127.0.0.1/32"}, ""},
+		{"no trusted proxies", "Remote-User", nil, ""},
+		{"header not configured", "", []string{"0.0.0.0/32"}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			useAuthConfig(t, tt.header, tt.trusted...)
+			app, store := newAuthTestApp(t)
+			req := httptest.NewRequest(http.MethodGet, "/whoami", nil)
+			req.Header.Set("Remote-User", "carol")
+			if _, got := authRequest(t, app, req); got != tt.want {
+				t.Errorf("signed in as %q, want %q", got, tt.want)
+			}
+			_, err := store.UserByName(context.Background(), "carol")
+			if created := err == nil; created != (tt.want != "") {
+				t.Errorf("account created = %v, want %v", created, tt.want != "")
+			}
+		})
+	}
+}
+
+func TestAuthenticateSessionCookie(t *testing.T) {
+	useAuthConfig(t, "")
+	app, store := newAuthTestApp(t)
+	ctx := context.Background()
+	user, err := store.CreateUser(ctx, "alice", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := store.CreateSession(ctx, hashSessionToken("live"), user.ID, time.Now().Add(time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.CreateSession(ctx, hashSessionToken("expired"), user.ID, time.Now().Add(-time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		token   string
+		want    string
+		cleared bool
+	}{
+		{"live", "alice", false},
+		{"expired", "", true},
+		// The store keeps hashes; the hash itself is not a token
+		{hashSessionToken("live"), "", true},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, "/whoami", nil)
+		req.AddCookie(&http.Cookie{Name: sessionCookieName, Value: tt.token})
+		resp, err := app.Test(req, -1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		if string(body) != tt.want {
+			t.Errorf("token %q signed in as %q, want %q", tt.token, body, tt.want)
+		}
+		cleared := strings.Contains(resp.Header.Get("Set-Cookie"), sessionCookieName+"=;")
+		if cleared != tt.cleared {
+			t.Errorf("token %q: cookie cleared = %v, want %v (Set-Cookie %q)", tt.token, cleared, tt.cleared, resp.Header.Get("Set-Cookie"))
+		}
+	}
+}
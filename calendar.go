@@ -0,0 +1,382 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"cineseer/components"
+	"cineseer/tmdb"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+const (
+	// Pages of /movie/upcoming included in a feed of upcoming movies
+	calendarUpcomingPages = 2
+
+	// Most IDs a feed can be filtered to, per media type
+	calendarMaxIDs = 50
+
+	// How far back feeds reach, so a release stays on the calendar for a while
+	// after it happens rather than vanishing on the day
+	calendarLookback = 30 * 24 * time.Hour
+
+	// Suffix of every event UID; UIDs only need to be unique to this feed
+	calendarUIDDomain = "cineseer"
+)
+
+// Release types as shown in event titles
+var releaseTypeNames = map[int]string{
+	tmdb.ReleasePremiere:          "Premiere",
+	tmdb.ReleaseTheatricalLimited: "Limited release",
+	tmdb.ReleaseTheatrical:        "In theaters",
+	tmdb.ReleaseDigital:           "Digital release",
+	tmdb.ReleasePhysical:          "Physical release",
+	tmdb.ReleaseTV:                "TV premiere",
+}
+
+// calendarEvent is an all-day release of a movie or an episode's air date
+type calendarEvent struct {
+	UID         string
+	Date        time.Time
+	Title       string // the full event title, e.g. "Andor S01E02: Rix Road"
+	Name        string // the movie or series
+	Detail      string // the kind of release, or the episode
+	Description string
+	Path        string // page on this site, relative to the base path
+	Kind        string // "movie" or "series"
+	MediaID     int
+	HasPoster   bool
+}
+
+var regionCode = regexp.MustCompile(`^[A-Z]{2}$`)
+
+// calendarRegion reads ?region= as an ISO 3166-1 code, defaulting to US
+func calendarRegion(c *fiber.Ctx) (string, error) {
+	region := strings.ToUpper(c.Query("region", "US"))
+	if !regionCode.MatchString(region) {
+		return "", fmt.Errorf("invalid region %q", c.Query("region"))
+	}
+	return region, nil
+}
+
+// parseIDList parses a comma-separated list of TMDB IDs, dropping duplicates
+func parseIDList(value string) ([]int, error) {
+	var ids []int
+	seen := make(map[int]bool)
+	for _, field := range strings.Split(value, ",") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+		id, err := strconv.Atoi(field)
+		if err != nil || id <= 0 {
+			return nil, fmt.Errorf("invalid ID %q", field)
+		}
+		if !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+	if len(ids) > calendarMaxIDs {
+		return nil, fmt.Errorf("at most %d IDs are allowed", calendarMaxIDs)
+	}
+	return ids, nil
+}
+
+// tmdbDate parses a TMDB date, which release_dates give as a full timestamp
+func tmdbDate(value string) (time.Time, bool) {
+	if len(value) < 10 {
+		return time.Time{}, false
+	}
+	t, err := time.Parse("2006-01-02", value[:10])
+	return t, err == nil
+}
+
+// upcomingMovieIDs lists the movies TMDB expects to release soon in region
+func upcomingMovieIDs(ctx context.Context, client *tmdb.Client, region string) ([]int, error) {
+	var ids []int
+	seen := make(map[int]bool)
+	for page := 1; page <= calendarUpcomingPages; page++ {
+		response, err := client.UpcomingMoviesInRegion(ctx, region, page)
+		if err != nil {
+			if page == 1 {
+				return nil, err
+			}
+			log.Printf("Error getting upcoming movies page %d for %s: %v", page, region, err)
+			break
+		}
+		for _, movie := range response.Results {
+			if !seen[movie.ID] {
+				seen[movie.ID] = true
+				ids = append(ids, movie.ID)
+			}
+		}
+		if page >= response.TotalPages {
+			break
+		}
+	}
+	return ids, nil
+}
+
+// movieReleaseEvents lists each kind of release of the given movies in
+// region since the given day. Movies with no dates for the region fall back
+// to their primary release date.
+func movieReleaseEvents(ctx context.Context, client *tmdb.Client, region string, ids []int, since time.Time) []calendarEvent {
+	results := make([][]calendarEvent, len(ids))
+	var wg sync.WaitGroup
+	for i, id := range ids {
+		wg.Add(1)
+		go func(i int, id int) {
+			defer wg.Done()
+			details, err := client.MovieDetails(ctx, id, tmdb.AppendReleaseDates)
+			if err != nil {
+				log.Printf("Error getting release dates for movie %d: %v", id, err)
+				return
+			}
+			results[i] = movieEvents(details, region, since)
+		}(i, id)
+	}
+	wg.Wait()
+
+	var events []calendarEvent
+	for _, r := range results {
+		events = append(events, r...)
+	}
+	return events
+}
+
+func movieEvents(details *tmdb.DetailedContent, region string, since time.Time) []calendarEvent {
+	path := fmt.Sprintf("/movie/%d", details.ID)
+	var events []calendarEvent
+
+	// The first date of each release type; re-releases aren't worth an event
+	seen := make(map[int]bool)
+	for _, release := range details.ReleaseDates.ForCountry(region) {
+		date, ok := tmdbDate(release.ReleaseDate)
+		if !ok || seen[release.Type] {
+			continue
+		}
+		seen[release.Type] = true
+		if date.Before(since) {
+			continue
+		}
+		kind, ok := releaseTypeNames[release.Type]
+		if !ok {
+			kind = "Release"
+		}
+		description := details.Overview
+		if release.Note != "" {
+			description = release.Note + "\n\n" + description
+		}
+		events = append(events, calendarEvent{
+			UID:         fmt.Sprintf("movie-%d-%s-%d@%s", details.ID, strings.ToLower(region), release.Type, calendarUIDDomain),
+			Date:        date,
+			Title:       fmt.Sprintf("%s (%s)", details.Title, kind),
+			Name:        details.Title,
+			Detail:      kind,
+			Description: description,
+			Path:        path,
+			Kind:        "movie",
+			MediaID:     details.ID,
+			HasPoster:   details.PosterPath != "",
+		})
+	}
+	if len(seen) > 0 {
+		return events
+	}
+
+	if date, ok := tmdbDate(details.ReleaseDate); ok && !date.Before(since) {
+		events = append(events, calendarEvent{
+			UID:         fmt.Sprintf("movie-%d@%s", details.ID, calendarUIDDomain),
+			Date:        date,
+			Title:       details.Title,
+			Name:        details.Title,
+			Detail:      "Release",
+			Description: details.Overview,
+			Path:        path,
+			Kind:        "movie",
+			MediaID:     details.ID,
+			HasPoster:   details.PosterPath != "",
+		})
+	}
+	return events
+}
+
+// episodeEvents lists the episodes of the given series airing since the
+// given day, from the seasons of their last and next episodes
+func episodeEvents(ctx context.Context, client *tmdb.Client, ids []int, since time.Time) []calendarEvent {
+	results := make([][]calendarEvent, len(ids))
+	var wg sync.WaitGroup
+	for i, id := range ids {
+		wg.Add(1)
+		go func(i int, id int) {
+			defer wg.Done()
+			series, err := client.SeriesDetails(ctx, id)
+			if err != nil {
+				log.Printf("Error getting series %d for calendar: %v", id, err)
+				return
+			}
+			results[i] = seriesEpisodeEvents(ctx, client, series, since)
+		}(i, id)
+	}
+	wg.Wait()
+
+	var events []calendarEvent
+	for _, r := range results {
+		events = append(events, r...)
+	}
+	return events
+}
+
+func seriesEpisodeEvents(ctx context.Context, client *tmdb.Client, series *tmdb.DetailedContent, since time.Time) []calendarEvent {
+	var seasons []int
+	for _, episode := range []*tmdb.Episode{series.LastEpisodeToAir, series.NextEpisodeToAir} {
+		if episode != nil && (len(seasons) == 0 || seasons[0] != episode.SeasonNumber) {
+			seasons = append(seasons, episode.SeasonNumber)
+		}
+	}
+
+	var events []calendarEvent
+	for _, number := range seasons {
+		season, err := client.SeasonDetails(ctx, series.ID, number)
+		if err != nil {
+			log.Printf("Error getting season %d of series %d for calendar: %v", number, series.ID, err)
+			continue
+		}
+		for _, episode := range season.Episodes {
+			date, ok := tmdbDate(episode.AirDate)
+			if !ok || date.Before(since) {
+				continue
+			}
+			detail := fmt.Sprintf("S%02dE%02d", number, episode.EpisodeNumber)
+			if episode.Name != "" {
+				detail += ": " + episode.Name
+			}
+			events = append(events, calendarEvent{
+				UID:         fmt.Sprintf("tv-%d-s%de%d@%s", series.ID, number, episode.EpisodeNumber, calendarUIDDomain),
+				Date:        date,
+				Title:       series.Name + " " + detail,
+				Name:        series.Name,
+				Detail:      detail,
+				Description: episode.Overview,
+				Path:        components.EpisodePath(series.ID, number, episode.EpisodeNumber),
+				Kind:        "series",
+				MediaID:     series.ID,
+				HasPoster:   series.PosterPath != "",
+			})
+		}
+	}
+	return events
+}
+
+// sortEvents orders events by date, then UID so feeds are stable
+func sortEvents(events []calendarEvent) {
+	sort.Slice(events, func(i, j int) bool {
+		if !events[i].Date.Equal(events[j].Date) {
+			return events[i].Date.Before(events[j].Date)
+		}
+		return events[i].UID < events[j].UID
+	})
+}
+
+// writeICalendar renders events as an iCalendar feed; siteURL makes their
+// links absolute
+func writeICalendar(name, siteURL string, events []calendarEvent, now time.Time) []byte {
+	var w icalWriter
+	w.prop("BEGIN", "VCALENDAR")
+	w.prop("VERSION", "2.0")
+	w.prop("PRODID", "-//CineSeer//Release Calendar//EN")
+	w.prop("CALSCALE", "GREGORIAN")
+	w.prop("METHOD", "PUBLISH")
+	w.text("X-WR-CALNAME", name)
+	w.prop("REFRESH-INTERVAL;VALUE=DURATION", "PT6H")
+	w.prop("X-PUBLISHED-TTL", "PT6H")
+	for _, event := range events {
+		w.prop("BEGIN", "VEVENT")
+		w.prop("UID", event.UID)
+		w.timestamp("DTSTAMP", now)
+		w.date("DTSTART", event.Date)
+		w.date("DTEND", event.Date.AddDate(0, 0, 1))
+		w.text("SUMMARY", event.Title)
+		if event.Description != "" {
+			w.text("DESCRIPTION", event.Description)
+		}
+		w.prop("URL", siteURL+event.Path)
+		if event.Kind == "movie" {
+			w.text("CATEGORIES", "Movie")
+		} else {
+			w.text("CATEGORIES", "TV")
+		}
+		w.prop("TRANSP", "TRANSPARENT")
+		w.prop("END", "VEVENT")
+	}
+	w.prop("END", "VCALENDAR")
+	return w.Bytes()
+}
+
+// setupCalendarFeed serves /calendar.ics, a subscribable feed of movie
+// releases and episode air dates.
+//
+//	?region=US         country whose release dates are used
+//	?movies=upcoming   TMDB's upcoming movies, or a comma-separated list of IDs
+//	?series=1399,94997 series whose episodes to include
+//
+// Without movies or series the feed lists upcoming movies.
+func setupCalendarFeed(app *fiber.App, client *tmdb.Client, basePath string) {
+	app.Get(basePath+"/calendar.ics", func(c *fiber.Ctx) error {
+		region, err := calendarRegion(c)
+		if err != nil {
+			return c.Status(400).SendString(err.Error())
+		}
+		moviesParam, seriesParam := c.Query("movies"), c.Query("series")
+		if moviesParam == "" && seriesParam == "" {
+			moviesParam = "upcoming"
+		}
+
+		var movieIDs []int
+		if moviesParam == "upcoming" {
+			movieIDs, err = upcomingMovieIDs(c.Context(), client, region)
+			if err != nil {
+				log.Printf("Error getting upcoming movies for calendar: %v", err)
+				return c.Status(502).SendString("Could not load upcoming movies")
+			}
+		} else if movieIDs, err = parseIDList(moviesParam); err != nil {
+			return c.Status(400).SendString("movies: " + err.Error())
+		}
+		seriesIDs, err := parseIDList(seriesParam)
+		if err != nil {
+			return c.Status(400).SendString("series: " + err.Error())
+		}
+
+		now := time.Now()
+		since := now.Add(-calendarLookback).UTC().Truncate(24 * time.Hour)
+		var movieEvents, tvEvents []calendarEvent
+		var wg sync.WaitGroup
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			movieEvents = movieReleaseEvents(c.Context(), client, region, movieIDs, since)
+		}()
+		go func() {
+			defer wg.Done()
+			tvEvents = episodeEvents(c.Context(), client, seriesIDs, since)
+		}()
+		wg.Wait()
+		events := append(movieEvents, tvEvents...)
+		sortEvents(events)
+
+		log.Printf("Serving calendar feed (%s, %d events) to %s", region, len(events), c.IP())
+		c.Set(fiber.HeaderContentType, "text/calendar; charset=utf-8")
+		c.Set(fiber.HeaderContentDisposition, `inline; filename="cineseer.ics"`)
+		c.Set(fiber.HeaderCacheControl, "public, max-age=3600")
+		return c.Send(writeICalendar("CineSeer releases ("+region+")", c.BaseURL()+basePath, events, now))
+	})
+}
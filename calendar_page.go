@@ -0,0 +1,200 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"cineseer/components"
+	"cineseer/tmdb"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// calendarToday is the current date, as a UTC midnight like tmdbDate returns
+func calendarToday() time.Time {
+	y, m, d := time.Now().Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+// calendarRange returns the first day shown by a week or month view of the
+// given date and the day after the last. Weeks start on Monday; month views
+// are padded out to whole weeks.
+func calendarRange(view string, date time.Time) (time.Time, time.Time) {
+	startOfWeek := func(t time.Time) time.Time {
+		return t.AddDate(0, 0, -((int(t.Weekday()) + 6) % 7))
+	}
+	if view == components.CalendarMonth {
+		first := time.Date(date.Year(), date.Month(), 1, 0, 0, 0, 0, time.UTC)
+		last := first.AddDate(0, 1, -1)
+		return startOfWeek(first), startOfWeek(last).AddDate(0, 0, 7)
+	}
+	start := startOfWeek(date)
+	return start, start.AddDate(0, 0, 7)
+}
+
+// airingSeriesIDs lists the series airing today or in the coming week
+func airingSeriesIDs(ctx context.Context, client *tmdb.Client) []int {
+	var today, onTheAir *tmdb.Response
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		var err error
+		if today, err = client.AiringTodaySeries(ctx); err != nil {
+			log.Printf("Error getting series airing today: %v", err)
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		var err error
+		if onTheAir, err = client.OnTheAirSeries(ctx); err != nil {
+			log.Printf("Error getting series on the air: %v", err)
+		}
+	}()
+	wg.Wait()
+
+	var ids []int
+	seen := make(map[int]bool)
+	for _, response := range []*tmdb.Response{today, onTheAir} {
+		if response == nil {
+			continue
+		}
+		for _, series := range response.Results {
+			if !seen[series.ID] {
+				seen[series.ID] = true
+				ids = append(ids, series.ID)
+			}
+		}
+	}
+	return ids
+}
+
+// calendarProps lays out what is released or airs in a week or month view.
+// Movies are shown on their release dates in region, as in the iCalendar
+// feed. Episodes come from the current seasons of series that are on the
+// air, so views far from today are mostly empty.
+func calendarProps(ctx context.Context, client *tmdb.Client, view string, date time.Time, region string) components.CalendarProps {
+	start, end := calendarRange(view, date)
+
+	var movies, episodes []calendarEvent
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		ids, err := upcomingMovieIDs(ctx, client, region)
+		if err != nil {
+			log.Printf("Error getting upcoming movies for calendar: %v", err)
+			return
+		}
+		movies = movieReleaseEvents(ctx, client, region, ids, start)
+	}()
+	go func() {
+		defer wg.Done()
+		episodes = episodeEvents(ctx, client, airingSeriesIDs(ctx, client), start)
+	}()
+	wg.Wait()
+	events := append(movies, episodes...)
+	sortEvents(events)
+
+	byDate := make(map[string][]components.CalendarEntry)
+	for _, event := range events {
+		if event.Date.Before(start) || !event.Date.Before(end) {
+			continue
+		}
+		key := event.Date.Format("2006-01-02")
+		byDate[key] = append(byDate[key], components.CalendarEntry{
+			Kind:      event.Kind,
+			ID:        event.MediaID,
+			Name:      event.Name,
+			Detail:    event.Detail,
+			Path:      event.Path,
+			HasPoster: event.HasPoster,
+		})
+	}
+
+	today := calendarToday()
+	props := components.CalendarProps{
+		View:  view,
+		Date:  date.Format("2006-01-02"),
+		Today: today.Format("2006-01-02"),
+	}
+	if region != "US" {
+		props.Region = region
+	}
+	if view == components.CalendarMonth {
+		props.Title = date.Format("January 2006")
+		props.Prev = time.Date(date.Year(), date.Month()-1, 1, 0, 0, 0, 0, time.UTC).Format("2006-01-02")
+		props.Next = time.Date(date.Year(), date.Month()+1, 1, 0, 0, 0, 0, time.UTC).Format("2006-01-02")
+	} else {
+		last := end.AddDate(0, 0, -1)
+		props.Title = fmt.Sprintf("%s – %s", start.Format("January 2"), last.Format("January 2, 2006"))
+		props.Prev = start.AddDate(0, 0, -7).Format("2006-01-02")
+		props.Next = end.Format("2006-01-02")
+	}
+
+	var week []components.CalendarDay
+	for day := start; day.Before(end); day = day.AddDate(0, 0, 1) {
+		key := day.Format("2006-01-02")
+		week = append(week, components.CalendarDay{
+			Date:    key,
+			Weekday: day.Format("Mon"),
+			Day:     day.Day(),
+			Today:   day.Equal(today),
+			Outside: view == components.CalendarMonth && day.Month() != date.Month(),
+			Entries: byDate[key],
+		})
+		if len(week) == 7 {
+			props.Weeks = append(props.Weeks, week)
+			week = nil
+		}
+	}
+	return props
+}
+
+// calendarQuery reads the view, date and region of a calendar request
+func calendarQuery(c *fiber.Ctx) (string, time.Time, string, error) {
+	view := c.Query("view", components.CalendarWeek)
+	if view != components.CalendarWeek && view != components.CalendarMonth {
+		return "", time.Time{}, "", fmt.Errorf("invalid view %q", view)
+	}
+	date := calendarToday()
+	if value := strings.TrimSpace(c.Query("date")); value != "" {
+		parsed, err := time.Parse("2006-01-02", value)
+		if err != nil {
+			return "", time.Time{}, "", fmt.Errorf("invalid date %q", value)
+		}
+		date = parsed
+	}
+	region, err := calendarRegion(c)
+	if err != nil {
+		return "", time.Time{}, "", err
+	}
+	return view, date, region, nil
+}
+
+// setupCalendarPage serves the /calendar page and the fragment its week and
+// month navigation swaps in
+func setupCalendarPage(app *fiber.App, api fiber.Router, client *tmdb.Client, basePath string) {
+	app.Get(basePath+"/calendar", func(c *fiber.Ctx) error {
+		view, date, region, err := calendarQuery(c)
+		if err != nil {
+			return c.Status(400).SendString(err.Error())
+		}
+		log.Printf("Serving calendar %s of %s to %s", view, date.Format("2006-01-02"), c.IP())
+		return render(c, basePath, components.CalendarPage(calendarProps(c.Context(), client, view, date, region)))
+	})
+
+	api.Get("/calendar", func(c *fiber.Ctx) error {
+		view, date, region, err := calendarQuery(c)
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+		return render(c, basePath, components.CalendarView(calendarProps(c.Context(), client, view, date, region)))
+	})
+}
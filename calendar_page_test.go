@@ -0,0 +1,116 @@
+package main
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+
+	"cineseer/components"
+)
+
+func TestCalendarRange(t *testing.T) {
+	tests := []struct {
+		name  string
+		view  string
+		date  string
+		start string
+		end   string
+	}{
+		{"week of a Monday", components.CalendarWeek, "2026-10-12", "2026-10-12", "2026-10-19"},
+		{"week of a Sunday", components.CalendarWeek, "2026-10-18", "2026-10-12", "2026-10-19"},
+		{"week across new year", components.CalendarWeek, "2025-01-01", "2024-12-30", "2025-01-06"},
+		{"month starting on a Monday", components.CalendarMonth, "2026-06-17", "2026-06-01", "2026-07-06"},
+		{"month padded at both ends", components.CalendarMonth, "2026-02-01", "2026-01-26", "2026-03-02"},
+		{"month padded into the new year", components.CalendarMonth, "2025-12-31", "2025-12-01", "2026-01-05"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			date, _ := time.Parse("2006-01-02", tt.date)
+			start, end := calendarRange(tt.view, date)
+			if got := start.Format("2006-01-02"); got != tt.start {
+				t.Errorf("start = %s, want %s", got, tt.start)
+			}
+			if got := end.Format("2006-01-02"); got != tt.end {
+				t.Errorf("end = %s, want %s", got, tt.end)
+			}
+		})
+	}
+}
+
+func TestCalendarProps(t *testing.T) {
+	// Dune's primary release is in March, but it opens earlier in the US and GB
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/movie/upcoming":
+			io.WriteString(w, `{"page": 1, "total_pages": 1, "results": [{"id": 10, "title": "Dune", "release_date": "2026-03-01"}]}`)
+		case "/movie/10":
+			io.WriteString(w, `{"id": 10, "title": "Dune", "release_date": "2026-03-01", "release_dates": {"results": [
+				{"iso_3166_1": "US", "release_dates": [{"release_date": "2026-01-02T00:00:00.000Z", "type": 3}]},
+				{"iso_3166_1": "GB", "release_dates": [{"release_date": "2026-01-20T00:00:00.000Z", "type": 3}]}
+			]}}`)
+		default:
+			io.WriteString(w, `{"results": []}`)
+		}
+	})
+
+	tests := []struct {
+		name   string
+		view   string
+		date   string
+		region string
+		first  string
+		last   string
+		weeks  int
+		title  string
+		prev   string
+		next   string
+		movie  string // day Dune is shown on, "" for none
+	}{
+		{"month", components.CalendarMonth, "2026-01-15", "US", "2025-12-29", "2026-02-01", 5, "January 2026", "2025-12-01", "2026-02-01", "2026-01-02"},
+		{"month in another region", components.CalendarMonth, "2026-01-15", "GB", "2025-12-29", "2026-02-01", 5, "January 2026", "2025-12-01", "2026-02-01", "2026-01-20"},
+		{"region without dates falls back to the primary one", components.CalendarMonth, "2026-01-15", "FR", "2025-12-29", "2026-02-01", 5, "January 2026", "2025-12-01", "2026-02-01", ""},
+		{"month rolling into the next year", components.CalendarMonth, "2025-12-01", "US", "2025-12-01", "2026-01-04", 5, "December 2025", "2025-11-01", "2026-01-01", "2026-01-02"},
+		{"week across new year", components.CalendarWeek, "2025-12-31", "US", "2025-12-29", "2026-01-04", 1, "December 29 – January 4, 2026", "2025-12-22", "2026-01-05", "2026-01-02"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			date, _ := time.Parse("2006-01-02", tt.date)
+			props := calendarProps(context.Background(), client, tt.view, date, tt.region)
+
+			if len(props.Weeks) != tt.weeks {
+				t.Fatalf("%d weeks, want %d", len(props.Weeks), tt.weeks)
+			}
+			var days []components.CalendarDay
+			for _, week := range props.Weeks {
+				if len(week) != 7 || week[0].Weekday != "Mon" {
+					t.Errorf("week starting %s has %d days from %s, want 7 from Monday", week[0].Date, len(week), week[0].Weekday)
+				}
+				days = append(days, week...)
+			}
+			if first, last := days[0].Date, days[len(days)-1].Date; first != tt.first || last != tt.last {
+				t.Errorf("days %s to %s, want %s to %s", first, last, tt.first, tt.last)
+			}
+			if props.Title != tt.title || props.Prev != tt.prev || props.Next != tt.next {
+				t.Errorf("title %q, prev %s, next %s; want %q, %s, %s", props.Title, props.Prev, props.Next, tt.title, tt.prev, tt.next)
+			}
+
+			var movie string
+			for _, day := range days {
+				inMonth := day.Date[:7] == tt.date[:7]
+				if want := tt.view == components.CalendarMonth && !inMonth; day.Outside != want {
+					t.Errorf("%s Outside = %v, want %v", day.Date, day.Outside, want)
+				}
+				for _, entry := range day.Entries {
+					if entry.Kind == "movie" && entry.ID == 10 {
+						movie = day.Date
+					}
+				}
+			}
+			if movie != tt.movie {
+				t.Errorf("Dune shown on %q, want %q", movie, tt.movie)
+			}
+		})
+	}
+}
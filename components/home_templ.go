@@ -41,7 +41,22 @@ func Home() templ.Component {
 				}()
 			}
 			ctx = templ.InitializeContext(ctx)
-			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString("<section id=\"trending-tv\"><h2>Trending TV Shows</h2><div class=\"media-container\" hx-get=\"./api/home?type=trending_tv\" hx-trigger=\"load\"><div class=\"loading\">Loading...</div></div></section><section id=\"trending-movies\"><h2>Trending Movies</h2><div class=\"media-container\" hx-get=\"./api/home?type=trending_movies\" hx-trigger=\"load\"><div class=\"loading\">Loading...</div></div></section><section id=\"popular-tv\"><h2>Popular TV Shows</h2><div class=\"media-container\" hx-get=\"./api/home?type=popular_tv\" hx-trigger=\"load\"><div class=\"loading\">Loading...</div></div></section><section id=\"popular-movies\"><h2>Popular Movies</h2><div class=\"media-container\" hx-get=\"./api/home?type=popular_movies\" hx-trigger=\"load\"><div class=\"loading\">Loading...</div></div></section><section id=\"upcoming-movies\"><h2>Upcoming Movies</h2><div class=\"media-container\" hx-get=\"./api/home?type=upcoming_movies\" hx-trigger=\"load\"><div class=\"loading\">Loading...</div></div></section><section id=\"recommended-tv\"><h2>Recommended TV Shows</h2><div class=\"media-container\" hx-get=\"./api/home?type=recommended_tv\" hx-trigger=\"load\"><div class=\"loading\">Loading...</div></div></section><section id=\"recommended-movies\"><h2>Recommended Movies</h2><div class=\"media-container\" hx-get=\"./api/home?type=recommended_movies\" hx-trigger=\"load\"><div class=\"loading\">Loading...</div></div></section>")
+			if CurrentViewer(ctx).SignedIn() {
+				_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString("<section id=\"up-next\"><h2><a href=\"")
+				if templ_7745c5c3_Err != nil {
+					return templ_7745c5c3_Err
+				}
+				var templ_7745c5c3_Var3 templ.SafeURL = templ.SafeURL(URL(ctx, "/up-next"))
+				_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(string(templ_7745c5c3_Var3)))
+				if templ_7745c5c3_Err != nil {
+					return templ_7745c5c3_Err
+				}
+				_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString("\" class=\"home-link\">Up Next</a></h2><div class=\"media-container\" hx-get=\"./api/home?type=up_next\" hx-trigger=\"load\"><div class=\"loading\">Loading...</div></div></section><section id=\"your-watchlist\"><h2>Your Watchlist</h2><div class=\"media-container\" hx-get=\"./api/home?type=watchlist\" hx-trigger=\"load\"><div class=\"loading\">Loading...</div></div></section>")
+				if templ_7745c5c3_Err != nil {
+					return templ_7745c5c3_Err
+				}
+			}
+			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(" <section id=\"trending-tv\"><h2>Trending TV Shows</h2><div class=\"media-container\" hx-get=\"./api/home?type=trending_tv\" hx-trigger=\"load\"><div class=\"loading\">Loading...</div></div></section><section id=\"trending-movies\"><h2>Trending Movies</h2><div class=\"media-container\" hx-get=\"./api/home?type=trending_movies\" hx-trigger=\"load\"><div class=\"loading\">Loading...</div></div></section><section id=\"popular-tv\"><h2>Popular TV Shows</h2><div class=\"media-container\" hx-get=\"./api/home?type=popular_tv\" hx-trigger=\"load\"><div class=\"loading\">Loading...</div></div></section><section id=\"popular-movies\"><h2>Popular Movies</h2><div class=\"media-container\" hx-get=\"./api/home?type=popular_movies\" hx-trigger=\"load\"><div class=\"loading\">Loading...</div></div></section><section id=\"upcoming-movies\"><h2>Upcoming Movies</h2><div class=\"media-container\" hx-get=\"./api/home?type=upcoming_movies\" hx-trigger=\"load\"><div class=\"loading\">Loading...</div></div></section><section id=\"recommended-tv\"><h2>Recommended TV Shows</h2><div class=\"media-container\" hx-get=\"./api/home?type=recommended_tv\" hx-trigger=\"load\"><div class=\"loading\">Loading...</div></div></section><section id=\"recommended-movies\"><h2>Recommended Movies</h2><div class=\"media-container\" hx-get=\"./api/home?type=recommended_movies\" hx-trigger=\"load\"><div class=\"loading\">Loading...</div></div></section>")
 			if templ_7745c5c3_Err != nil {
 				return templ_7745c5c3_Err
 			}
@@ -72,9 +87,9 @@ func MediaList(items []MediaCardProps) templ.Component {
 			}()
 		}
 		ctx = templ.InitializeContext(ctx)
-		templ_7745c5c3_Var3 := templ.GetChildren(ctx)
-		if templ_7745c5c3_Var3 == nil {
-			templ_7745c5c3_Var3 = templ.NopComponent
+		templ_7745c5c3_Var4 := templ.GetChildren(ctx)
+		if templ_7745c5c3_Var4 == nil {
+			templ_7745c5c3_Var4 = templ.NopComponent
 		}
 		ctx = templ.ClearChildren(ctx)
 		for _, item := range items {
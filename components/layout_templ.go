@@ -42,7 +42,117 @@ func Layout(title string) templ.Component {
 		if templ_7745c5c3_Err != nil {
 			return templ_7745c5c3_Err
 		}
-		_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString("</title><script src=\"https://unpkg.com/htmx.org@1.9.10\"></script><script>\n            // Mobile touch handling\n            document.addEventListener('DOMContentLoaded', function() {\n                if (window.matchMedia('(max-width: 768px)').matches) {\n                    document.addEventListener('click', function(e) {\n                        const card = e.target.closest('.media-card');\n                        if (card) {\n                            document.querySelectorAll('.media-card').forEach(c => {\n                                if (c !== card) c.classList.remove('active');\n                            });\n                            card.classList.toggle('active');\n                        } else {\n                            document.querySelectorAll('.media-card').forEach(c => \n                                c.classList.remove('active')\n                            );\n                        }\n                    });\n                }\n            });\n        </script><style>\n            * {\n                margin: 0;\n                padding: 0;\n                box-sizing: border-box;\n            }\n\n            body {\n                font-family: system-ui, -apple-system, sans-serif;\n                background: #0f172a;\n                color: #e2e8f0;\n                padding: clamp(0.5rem, 3vw, 2rem);\n            }\n\n            h1, h2 {\n                margin-bottom: clamp(0.67rem, 2.7vw, 1.33rem);\n                text-align: left;\n                color: #f8fafc;\n                font-size: clamp(1.25rem, 4vw, 2rem);\n            }\n\n            h2 {\n                margin-top: clamp(1.33rem, 4vw, 2rem);\n                font-size: clamp(1.1rem, 3.5vw, 1.75rem);\n            }\n\n            .media-container {\n                display: grid;\n                grid-auto-flow: column;\n                grid-auto-columns: clamp(126px, 31.5vw, 12rem);\n                gap: clamp(0.5rem, 2vw, 1.5rem);\n                overflow-x: auto;\n                padding: clamp(0.5rem, 2vw, 1rem);\n                scroll-snap-type: x mandatory;\n                scrollbar-width: none;\n                -ms-overflow-style: none;\n                -webkit-overflow-scrolling: touch;\n                min-height: 280px;\n            }\n\n            .media-container::-webkit-scrollbar {\n                display: none;\n            }\n\n            .loading {\n                display: flex;\n                align-items: center;\n                justify-content: center;\n                width: 100%;\n                height: 280px;\n                color: #94a3b8;\n            }\n\n            .error {\n                color: #ef4444;\n                padding: 1rem;\n                background: rgba(239, 68, 68, 0.1);\n                border-radius: 0.5rem;\n                margin: 1rem 0;\n            }\n\n            header {\n                display: flex;\n                align-items: center;\n                justify-content: space-between;\n                margin-bottom: 2rem;\n                padding-bottom: 1rem;\n                border-bottom: 1px solid #1e293b;\n            }\n\n            .home-link {\n                text-decoration: none;\n                color: inherit;\n                transition: color 0.2s;\n            }\n\n            .home-link:hover {\n                color: #60a5fa;\n            }\n\n            nav {\n                display: flex;\n                gap: 1.5rem;\n            }\n\n            nav a {\n                color: #94a3b8;\n                text-decoration: none;\n                transition: color 0.2s;\n                font-size: 1.1rem;\n            }\n\n            nav a:hover {\n                color: #60a5fa;\n            }\n\n            main {\n                scroll-padding-top: 2rem;\n            }\n\n            /* Media Card Styles */\n            .media-link {\n                text-decoration: none;\n                color: inherit;\n            }\n\n            .media-card {\n                position: relative;\n                border-radius: 0.5rem;\n                overflow: hidden;\n                scroll-snap-align: start;\n                background: #1e293b;\n                transition: transform 0.2s;\n                aspect-ratio: 3/4;\n                height: auto;\n                max-height: clamp(196px, 42vh, 280px);\n            }\n\n            @media (hover: hover) {\n                .media-card:hover {\n                    transform: translateY(-5px);\n                }\n\n                .media-card:hover .media-info {\n                    transform: translateY(0);\n                }\n\n                .media-card:hover .media-image {\n                    opacity: 0.7;\n                }\n            }\n\n            .media-image-container {\n                position: relative;\n                width: 100%;\n                height: 100%;\n                background: #1e293b;\n            }\n\n            .media-image-container::before {\n                content: '';\n                position: absolute;\n                top: 0;\n                left: 0;\n                width: 100%;\n                height: 100%;\n                background: linear-gradient(90deg, #1e293b 25%, #2d3c50 50%, #1e293b 75%);\n                background-size: 200% 100%;\n                animation: loading 1.5s infinite;\n            }\n\n            .media-image-container.loaded::before {\n                display: none;\n            }\n\n            .media-image-container.error::before {\n                animation: none;\n                background: #1e293b;\n            }\n\n            .media-image {\n                position: absolute;\n                top: 0;\n                left: 0;\n                width: 100%;\n                height: 100%;\n                object-fit: cover;\n                transition: opacity 0.3s;\n                opacity: 0;\n            }\n\n            .media-image-container.loaded .media-image {\n                opacity: 1;\n            }\n\n            @keyframes loading {\n                0% { background-position: 200% 0; }\n                100% { background-position: -200% 0; }\n            }\n\n            .media-info {\n                position: absolute;\n                bottom: 0;\n                left: 0;\n                right: 0;\n                padding: clamp(0.5rem, 2vw, 1rem);\n                background: rgba(15, 23, 42, 0.9);\n                transform: translateY(100%);\n                transition: transform 0.3s;\n            }\n\n            @media (max-width: 768px) {\n                .media-info {\n                    background: rgba(15, 23, 42, 0.95);\n                }\n\n                .media-overview {\n                    -webkit-line-clamp: 2;\n                }\n\n                .media-card.active .media-info {\n                    transform: translateY(0);\n                }\n\n                .media-card.active .media-image {\n                    opacity: 0.7;\n                }\n            }\n\n            .media-title {\n                font-size: clamp(0.875rem, 2.5vw, 1.25rem);\n                font-weight: bold;\n                margin-bottom: 0.25rem;\n                color: #f8fafc;\n            }\n\n            .media-year {\n                font-size: clamp(0.75rem, 1.8vw, 0.875rem);\n                color: #94a3b8;\n                margin-bottom: 0.25rem;\n            }\n\n            .media-overview {\n                font-size: clamp(0.75rem, 1.8vw, 0.875rem);\n                color: #cbd5e1;\n                display: -webkit-box;\n                -webkit-line-clamp: 3;\n                -webkit-box-orient: vertical;\n                overflow: hidden;\n            }\n\n            /* Detail Page Styles */\n            body.detail-page {\n                background-size: cover;\n                background-position: center;\n                background-attachment: fixed;\n                position: relative;\n            }\n\n            body.detail-page::before {\n                content: '';\n                position: fixed;\n                top: 0;\n                left: 0;\n                right: 0;\n                bottom: 0;\n                background: rgba(15, 23, 42, 0.85);\n                z-index: 0;\n            }\n\n            .back-button {\n                display: inline-block;\n                margin-bottom: 2rem;\n                color: #94a3b8;\n                text-decoration: none;\n                font-size: 0.9rem;\n                position: relative;\n                z-index: 1;\n            }\n\n            .back-button:hover {\n                color: #e2e8f0;\n            }\n        </style></head><body><header><h1><a href=\"/\" class=\"home-link\">CineSeer</a></h1><nav><a href=\"#trending-tv\">TV Shows</a> <a href=\"#trending-movies\">Movies</a></nav></header><main>")
+		_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString("</title><script src=\"https://unpkg.com/htmx.org@1.9.10\"></script><script>\n            // Mobile touch handling\n            document.addEventListener('DOMContentLoaded', function() {\n                if (window.matchMedia('(max-width: 768px)').matches) {\n                    document.addEventListener('click', function(e) {\n                        const card = e.target.closest('.media-card');\n                        if (card) {\n                            document.querySelectorAll('.media-card').forEach(c => {\n                                if (c !== card) c.classList.remove('active');\n                            });\n                            card.classList.toggle('active');\n                        } else {\n                            document.querySelectorAll('.media-card').forEach(c => \n                                c.classList.remove('active')\n                            );\n                        }\n                    });\n                }\n            });\n        </script><style>\n            * {\n                margin: 0;\n                padding: 0;\n                box-sizing: border-box;\n            }\n\n            body {\n                font-family: system-ui, -apple-system, sans-serif;\n                background: #0f172a;\n                color: #e2e8f0;\n                padding: clamp(0.5rem, 3vw, 2rem);\n            }\n\n            h1, h2 {\n                margin-bottom: clamp(0.67rem, 2.7vw, 1.33rem);\n                text-align: left;\n                color: #f8fafc;\n                font-size: clamp(1.25rem, 4vw, 2rem);\n            }\n\n            h2 {\n                margin-top: clamp(1.33rem, 4vw, 2rem);\n                font-size: clamp(1.1rem, 3.5vw, 1.75rem);\n            }\n\n            .media-container {\n                display: grid;\n                grid-auto-flow: column;\n                grid-auto-columns: clamp(126px, 31.5vw, 12rem);\n                gap: clamp(0.5rem, 2vw, 1.5rem);\n                overflow-x: auto;\n                padding: clamp(0.5rem, 2vw, 1rem);\n                scroll-snap-type: x mandatory;\n                scrollbar-width: none;\n                -ms-overflow-style: none;\n                -webkit-overflow-scrolling: touch;\n                min-height: 280px;\n            }\n\n            .media-container::-webkit-scrollbar {\n                display: none;\n            }\n\n            .loading {\n                display: flex;\n                align-items: center;\n                justify-content: center;\n                width: 100%;\n                height: 280px;\n                color: #94a3b8;\n            }\n\n            .error {\n                color: #ef4444;\n                padding: 1rem;\n                background: rgba(239, 68, 68, 0.1);\n                border-radius: 0.5rem;\n                margin: 1rem 0;\n            }\n\n            header {\n                display: flex;\n                align-items: center;\n                justify-content: space-between;\n                margin-bottom: 2rem;\n                padding-bottom: 1rem;\n                border-bottom: 1px solid #1e293b;\n            }\n\n            .home-link {\n                text-decoration: none;\n                color: inherit;\n                transition: color 0.2s;\n            }\n\n            .home-link:hover {\n                color: #60a5fa;\n            }\n\n            nav {\n                display: flex;\n                align-items: center;\n                gap: 1.5rem;\n            }\n\n            nav a {\n                color: #94a3b8;\n                text-decoration: none;\n                transition: color 0.2s;\n                font-size: 1.1rem;\n            }\n\n            nav a:hover {\n                color: #60a5fa;\n            }\n\n            .nav-user {\n                color: #e2e8f0;\n                font-size: 1.1rem;\n            }\n\n            .nav-form button {\n                background: none;\n                border: none;\n                color: #94a3b8;\n                font: inherit;\n                font-size: 1.1rem;\n                cursor: pointer;\n                transition: color 0.2s;\n            }\n\n            .nav-form button:hover {\n                color: #60a5fa;\n            }\n\n            main {\n                scroll-padding-top: 2rem;\n            }\n\n            /* Search Styles */\n            .search-box {\n                flex: 1;\n                max-width: 28rem;\n                margin: 0 1.5rem;\n            }\n\n            .search-box input {\n                width: 100%;\n                padding: 0.5rem 0.75rem;\n                border-radius: 0.5rem;\n                border: 1px solid #334155;\n                background: #1e293b;\n                color: #e2e8f0;\n                font-size: 1rem;\n            }\n\n            .search-box input:focus {\n                outline: none;\n                border-color: #60a5fa;\n            }\n\n            .search-dropdown {\n                position: relative;\n                z-index: 2;\n                margin-bottom: 2rem;\n                background: #0f172a;\n                border: 1px solid #1e293b;\n                border-radius: 0.5rem;\n            }\n\n            .search-dropdown:empty {\n                display: none;\n            }\n\n            .media-grid {\n                display: grid;\n                grid-template-columns: repeat(auto-fill, minmax(clamp(126px, 31.5vw, 12rem), 1fr));\n                gap: clamp(0.5rem, 2vw, 1.5rem);\n                padding: clamp(0.5rem, 2vw, 1rem) 0;\n            }\n\n            .pagination {\n                display: flex;\n                align-items: center;\n                justify-content: center;\n                gap: 1rem;\n                margin: 2rem 0;\n                color: #94a3b8;\n            }\n\n            .pagination a {\n                color: #e2e8f0;\n                text-decoration: none;\n                padding: 0.5rem 1rem;\n                border-radius: 0.25rem;\n                background: rgba(255, 255, 255, 0.1);\n            }\n\n            .pagination a:hover {\n                background: rgba(255, 255, 255, 0.2);\n            }\n\n            .search-form {\n                display: flex;\n                gap: 1rem;\n                margin-bottom: 1rem;\n            }\n\n            .search-form input,\n            .search-form select {\n                padding: 0.5rem 0.75rem;\n                border-radius: 0.5rem;\n                border: 1px solid #334155;\n                background: #1e293b;\n                color: #e2e8f0;\n                font-size: 1rem;\n            }\n\n            .search-form input {\n                flex: 1;\n            }\n\n            .search-summary {\n                color: #94a3b8;\n            }\n\n            /* Media Card Styles */\n            .media-item {\n                position: relative;\n            }\n\n            .media-link {\n                display: block;\n                text-decoration: none;\n                color: inherit;\n            }\n\n            /* Watchlist toggles */\n            .watch-toggle {\n                display: flex;\n                flex-wrap: wrap;\n                gap: 0.5rem;\n            }\n\n            .watch-toggle.compact {\n                position: absolute;\n                top: 0.4rem;\n                right: 0.4rem;\n                z-index: 2;\n                flex-direction: column;\n                gap: 0.25rem;\n            }\n\n            .watch-button,\n            .episode-watch {\n                padding: 0.4rem 0.9rem;\n                border: none;\n                border-radius: 0.375rem;\n                background: rgba(255, 255, 255, 0.1);\n                color: #e2e8f0;\n                font: inherit;\n                font-size: 0.95rem;\n                cursor: pointer;\n                transition: background 0.2s;\n            }\n\n            .watch-toggle.compact .watch-button {\n                width: 1.9rem;\n                height: 1.9rem;\n                padding: 0;\n                border-radius: 50%;\n                background: rgba(15, 23, 42, 0.8);\n                font-size: 0.85rem;\n            }\n\n            .watch-button:hover,\n            .episode-watch:hover {\n                background: rgba(59, 130, 246, 0.6);\n            }\n\n            .watch-toggle .watch-button.active,\n            .episode-watch.active {\n                background: #3b82f6;\n            }\n\n            .media-card {\n                position: relative;\n                border-radius: 0.5rem;\n                overflow: hidden;\n                scroll-snap-align: start;\n                background: #1e293b;\n                transition: transform 0.2s;\n                aspect-ratio: 3/4;\n                height: auto;\n                max-height: clamp(196px, 42vh, 280px);\n            }\n\n            @media (hover: hover) {\n                .media-card:hover {\n                    transform: translateY(-5px);\n                }\n\n                .media-card:hover .media-info {\n                    transform: translateY(0);\n                }\n\n                .media-card:hover .media-image {\n                    opacity: 0.7;\n                }\n            }\n\n            .media-image-container {\n                position: relative;\n                width: 100%;\n                height: 100%;\n                background: #1e293b;\n            }\n\n            .media-image-container::before {\n                content: '';\n                position: absolute;\n                top: 0;\n                left: 0;\n                width: 100%;\n                height: 100%;\n                background: linear-gradient(90deg, #1e293b 25%, #2d3c50 50%, #1e293b 75%);\n                background-size: 200% 100%;\n                animation: loading 1.5s infinite;\n            }\n\n            .media-image-container.loaded::before {\n                display: none;\n            }\n\n            .media-image-container.error::before {\n                animation: none;\n                background: #1e293b;\n            }\n\n            .media-image {\n                position: absolute;\n                top: 0;\n                left: 0;\n                width: 100%;\n                height: 100%;\n                object-fit: cover;\n                transition: opacity 0.3s;\n                opacity: 0;\n            }\n\n            .media-image-container.loaded .media-image {\n                opacity: 1;\n            }\n\n            @keyframes loading {\n                0% { background-position: 200% 0; }\n                100% { background-position: -200% 0; }\n            }\n\n            .media-info {\n                position: absolute;\n                bottom: 0;\n                left: 0;\n                right: 0;\n                padding: clamp(0.5rem, 2vw, 1rem);\n                background: rgba(15, 23, 42, 0.9);\n                transform: translateY(100%);\n                transition: transform 0.3s;\n            }\n\n            @media (max-width: 768px) {\n                .media-info {\n                    background: rgba(15, 23, 42, 0.95);\n                }\n\n                .media-overview {\n                    -webkit-line-clamp: 2;\n                }\n\n                .media-card.active .media-info {\n                    transform: translateY(0);\n                }\n\n                .media-card.active .media-image {\n                    opacity: 0.7;\n                }\n            }\n\n            .media-title {\n                font-size: clamp(0.875rem, 2.5vw, 1.25rem);\n                font-weight: bold;\n                margin-bottom: 0.25rem;\n                color: #f8fafc;\n            }\n\n            .media-year {\n                font-size: clamp(0.75rem, 1.8vw, 0.875rem);\n                color: #94a3b8;\n                margin-bottom: 0.25rem;\n            }\n\n            .media-reason {\n                color: #93c5fd;\n                font-size: 0.8rem;\n                font-style: italic;\n                margin-bottom: 0.25rem;\n            }\n\n            .media-overview {\n                font-size: clamp(0.75rem, 1.8vw, 0.875rem);\n                color: #cbd5e1;\n                display: -webkit-box;\n                -webkit-line-clamp: 3;\n                -webkit-box-orient: vertical;\n                overflow: hidden;\n            }\n\n            /* Detail Page Styles */\n            body.detail-page {\n                background-size: cover;\n                background-position: center;\n                background-attachment: fixed;\n                position: relative;\n            }\n\n            body.detail-page::before {\n                content: '';\n                position: fixed;\n                top: 0;\n                left: 0;\n                right: 0;\n                bottom: 0;\n                background: rgba(15, 23, 42, 0.85);\n                z-index: 0;\n            }\n\n            .back-button {\n                display: inline-block;\n                margin-bottom: 2rem;\n                color: #94a3b8;\n                text-decoration: none;\n                font-size: 0.9rem;\n                position: relative;\n                z-index: 1;\n            }\n\n            .back-button:hover {\n                color: #e2e8f0;\n            }\n        </style></head><body><header><h1><a href=\"/\" class=\"home-link\">CineSeer</a></h1><form class=\"search-box\" action=\"")
+		if templ_7745c5c3_Err != nil {
+			return templ_7745c5c3_Err
+		}
+		var templ_7745c5c3_Var3 templ.SafeURL = templ.SafeURL(URL(ctx, "/search"))
+		_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(string(templ_7745c5c3_Var3)))
+		if templ_7745c5c3_Err != nil {
+			return templ_7745c5c3_Err
+		}
+		_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString("\" method=\"get\"><input type=\"search\" name=\"q\" placeholder=\"Search movies, TV shows, people...\" autocomplete=\"off\" hx-get=\"")
+		if templ_7745c5c3_Err != nil {
+			return templ_7745c5c3_Err
+		}
+		var templ_7745c5c3_Var4 string
+		templ_7745c5c3_Var4, templ_7745c5c3_Err = templ.JoinStringErrs(URL(ctx, "/api/search"))
+		if templ_7745c5c3_Err != nil {
+			return templ.Error{Err: templ_7745c5c3_Err, FileName: `components/layout.templ`, Line: 472, Col: 52}
+		}
+		_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var4))
+		if templ_7745c5c3_Err != nil {
+			return templ_7745c5c3_Err
+		}
+		_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString("\" hx-vals=\"{&#34;compact&#34;: &#34;1&#34;}\" hx-trigger=\"input changed delay:300ms, search\" hx-target=\"#search-dropdown\"></form><nav><a href=\"#trending-tv\">TV Shows</a> <a href=\"#trending-movies\">Movies</a> <a href=\"")
+		if templ_7745c5c3_Err != nil {
+			return templ_7745c5c3_Err
+		}
+		var templ_7745c5c3_Var5 templ.SafeURL = templ.SafeURL(URL(ctx, "/discover"))
+		_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(string(templ_7745c5c3_Var5)))
+		if templ_7745c5c3_Err != nil {
+			return templ_7745c5c3_Err
+		}
+		_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString("\">Discover</a> <a href=\"")
+		if templ_7745c5c3_Err != nil {
+			return templ_7745c5c3_Err
+		}
+		var templ_7745c5c3_Var6 templ.SafeURL = templ.SafeURL(URL(ctx, "/calendar"))
+		_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(string(templ_7745c5c3_Var6)))
+		if templ_7745c5c3_Err != nil {
+			return templ_7745c5c3_Err
+		}
+		_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString("\">Calendar</a> ")
+		if templ_7745c5c3_Err != nil {
+			return templ_7745c5c3_Err
+		}
+		if CurrentViewer(ctx).SignedIn() {
+			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString("<a href=\"")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			var templ_7745c5c3_Var7 templ.SafeURL = templ.SafeURL(URL(ctx, "/up-next"))
+			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(string(templ_7745c5c3_Var7)))
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString("\">Up Next</a> <a href=\"")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			var templ_7745c5c3_Var8 templ.SafeURL = templ.SafeURL(URL(ctx, "/watchlist"))
+			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(string(templ_7745c5c3_Var8)))
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString("\">Watchlist</a> <span class=\"nav-user\">")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			var templ_7745c5c3_Var9 string
+			templ_7745c5c3_Var9, templ_7745c5c3_Err = templ.JoinStringErrs(CurrentViewer(ctx).Username)
+			if templ_7745c5c3_Err != nil {
+				return templ.Error{Err: templ_7745c5c3_Err, FileName: `components/layout.templ`, Line: 486, Col: 72}
+			}
+			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var9))
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString("</span> ")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			if !CurrentViewer(ctx).Proxy {
+				_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString("<form class=\"nav-form\" action=\"")
+				if templ_7745c5c3_Err != nil {
+					return templ_7745c5c3_Err
+				}
+				var templ_7745c5c3_Var10 templ.SafeURL = templ.SafeURL(URL(ctx, "/logout"))
+				_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(string(templ_7745c5c3_Var10)))
+				if templ_7745c5c3_Err != nil {
+					return templ_7745c5c3_Err
+				}
+				_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString("\" method=\"post\"><button type=\"submit\">Sign out</button></form>")
+				if templ_7745c5c3_Err != nil {
+					return templ_7745c5c3_Err
+				}
+			}
+		} else if CurrentViewer(ctx).Accounts {
+			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString("<a href=\"")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			var templ_7745c5c3_Var11 templ.SafeURL = templ.SafeURL(URL(ctx, "/login"))
+			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(string(templ_7745c5c3_Var11)))
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString("\">Sign in</a>")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+		}
+		_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString("</nav></header><div id=\"search-dropdown\" class=\"search-dropdown\"></div><main>")
 		if templ_7745c5c3_Err != nil {
 			return templ_7745c5c3_Err
 		}
@@ -0,0 +1,124 @@
+package components
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+type basePathKey struct{}
+
+// WithBasePath stores the BASE_PATH the app is mounted under so components
+// can build absolute links with URL.
+func WithBasePath(ctx context.Context, basePath string) context.Context {
+	return context.WithValue(ctx, basePathKey{}, basePath)
+}
+
+// URL prefixes path with the base path stored in ctx.
+func URL(ctx context.Context, path string) string {
+	basePath, _ := ctx.Value(basePathKey{}).(string)
+	return basePath + path
+}
+
+type viewerKey struct{}
+
+// Viewer is who is looking at a page, for the account links in the header
+// and personal features.
+type Viewer struct {
+	Username string // empty when signed out
+	Accounts bool   // whether the server has accounts at all
+	Proxy    bool   // signed in by a reverse proxy, which also handles signing out
+
+	// Watchlist statuses by WatchKey
+	Watchlist map[string]string
+}
+
+// WatchKey identifies a movie or series in Viewer.Watchlist.
+func WatchKey(kind string, id int) string {
+	return fmt.Sprintf("%s/%d", kind, id)
+}
+
+// WatchStatus returns the viewer's watchlist status of a title, or "" when
+// it isn't on their watchlist.
+func (v Viewer) WatchStatus(kind string, id int) string {
+	return v.Watchlist[WatchKey(kind, id)]
+}
+
+// SignedIn reports whether the viewer has an account.
+func (v Viewer) SignedIn() bool {
+	return v.Username != ""
+}
+
+// WithViewer stores the viewer of a page in ctx.
+func WithViewer(ctx context.Context, viewer Viewer) context.Context {
+	return context.WithValue(ctx, viewerKey{}, viewer)
+}
+
+// CurrentViewer returns the viewer stored in ctx.
+func CurrentViewer(ctx context.Context) Viewer {
+	viewer, _ := ctx.Value(viewerKey{}).(Viewer)
+	return viewer
+}
+
+// ImagePath returns the image proxy route for a movie, series, person or
+// collection image. kind uses page naming, so "series" maps to TMDB's "tv".
+func ImagePath(kind string, id int, imgType string) string {
+	if kind == "series" {
+		kind = "tv"
+	}
+	return fmt.Sprintf("/api/image/%s/%d/%s", kind, id, imgType)
+}
+
+// ImageURL is ImagePath prefixed with the base path stored in ctx.
+func ImageURL(ctx context.Context, kind string, id int, imgType string) string {
+	return URL(ctx, ImagePath(kind, id, imgType))
+}
+
+// Widths offered in srcset attributes. The card widths cover the media grid
+// at 1x and 2x; CardPosterWidth is the fallback src and what the background
+// warmer fetches.
+var (
+	PosterWidths  = []int{185, 342, 500}
+	ProfileWidths = []int{185, 300}
+)
+
+const CardPosterWidth = 342
+
+// CardImageSizes matches the media grid's column width: clamp(126px, 31.5vw, 12rem).
+const CardImageSizes = "(max-width: 400px) 126px, (max-width: 609px) 32vw, 192px"
+
+// ImageWidthURL is ImageURL resized to width pixels.
+func ImageWidthURL(ctx context.Context, kind string, id int, imgType string, width int) string {
+	return fmt.Sprintf("%s?w=%d", ImageURL(ctx, kind, id, imgType), width)
+}
+
+// ImageSrcset lists the image at each of widths for a srcset attribute.
+func ImageSrcset(ctx context.Context, kind string, id int, imgType string, widths []int) string {
+	candidates := make([]string, len(widths))
+	for i, w := range widths {
+		candidates[i] = fmt.Sprintf("%s %dw", ImageWidthURL(ctx, kind, id, imgType, w), w)
+	}
+	return strings.Join(candidates, ", ")
+}
+
+// SeasonPosterURL returns the proxied poster of a season at width pixels.
+func SeasonPosterURL(ctx context.Context, seriesID, season, width int) string {
+	return URL(ctx, fmt.Sprintf("/api/image/tv/%d/season/%d/poster?w=%d", seriesID, season, width))
+}
+
+// EpisodeStillURL returns the proxied main still of an episode at width
+// pixels.
+func EpisodeStillURL(ctx context.Context, seriesID, season, episode, width int) string {
+	return URL(ctx, fmt.Sprintf("/api/image/tv/%d/season/%d/episode/%d/still?w=%d", seriesID, season, episode, width))
+}
+
+// EpisodeGalleryURL returns the proxied index'th still of an episode's
+// image gallery at width pixels.
+func EpisodeGalleryURL(ctx context.Context, seriesID, season, episode, index, width int) string {
+	return URL(ctx, fmt.Sprintf("/api/image/tv/%d/season/%d/episode/%d/stills/%d?w=%d", seriesID, season, episode, index, width))
+}
+
+// EpisodePath returns the page of an episode.
+func EpisodePath(seriesID, season, episode int) string {
+	return fmt.Sprintf("/series/%d/season/%d/episode/%d", seriesID, season, episode)
+}
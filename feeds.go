@@ -0,0 +1,519 @@
+package main
+
+import (
+	"crypto/sha1"
+	"encoding/xml"
+	"fmt"
+	"html"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"cineseer/components"
+	"cineseer/tmdb"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+const (
+	// Most entries a feed lists, newest first
+	feedMaxEntries = 50
+
+	feedFormatAtom = "atom"
+	feedFormatRSS  = "rss"
+)
+
+// feed is a format-neutral feed, rendered as Atom or RSS
+type feed struct {
+	ID       string
+	Title    string
+	Subtitle string
+	Link     string // HTML page the feed follows
+	Self     string // the feed itself
+	Updated  time.Time
+	Entries  []feedEntry
+}
+
+type feedEntry struct {
+	ID        string
+	Title     string
+	Link      string
+	Summary   string
+	ImageURL  string
+	Published time.Time
+	Updated   time.Time
+}
+
+// content renders an entry as HTML: the poster, then the overview
+func (e feedEntry) content() string {
+	var b strings.Builder
+	if e.ImageURL != "" {
+		fmt.Fprintf(&b, `<p><a href="%s"><img src="%s" alt="%s"/></a></p>`,
+			html.EscapeString(e.Link), html.EscapeString(e.ImageURL), html.EscapeString(e.Title))
+	}
+	if e.Summary != "" {
+		fmt.Fprintf(&b, "<p>%s</p>", html.EscapeString(e.Summary))
+	}
+	return b.String()
+}
+
+// feedUUID derives a stable urn:uuid (version 5 style) from name, so entry
+// IDs don't depend on the host the feed was fetched from
+func feedUUID(name string) string {
+	sum := sha1.Sum([]byte("cineseer:" + name))
+	sum[6] = sum[6]&0x0f | 0x50
+	sum[8] = sum[8]&0x3f | 0x80
+	return fmt.Sprintf("urn:uuid:%x-%x-%x-%x-%x", sum[0:4], sum[4:6], sum[6:8], sum[8:10], sum[10:16])
+}
+
+// finish sorts entries newest first, caps them, and dates the feed by its
+// newest entry unless it already has a date
+func (f *feed) finish() {
+	sort.SliceStable(f.Entries, func(i, j int) bool {
+		return f.Entries[i].Updated.After(f.Entries[j].Updated)
+	})
+	if len(f.Entries) > feedMaxEntries {
+		f.Entries = f.Entries[:feedMaxEntries]
+	}
+	if f.Updated.IsZero() && len(f.Entries) > 0 {
+		f.Updated = f.Entries[0].Updated
+	}
+}
+
+type atomFeed struct {
+	XMLName  xml.Name    `xml:"http://www.w3.org/2005/Atom feed"`
+	ID       string      `xml:"id"`
+	Title    string      `xml:"title"`
+	Subtitle string      `xml:"subtitle,omitempty"`
+	Updated  string      `xml:"updated"`
+	Links    []atomLink  `xml:"link"`
+	Author   atomAuthor  `xml:"author"`
+	Entries  []atomEntry `xml:"entry"`
+}
+
+type atomLink struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr,omitempty"`
+	Type string `xml:"type,attr,omitempty"`
+}
+
+type atomAuthor struct {
+	Name string `xml:"name"`
+}
+
+type atomEntry struct {
+	ID        string      `xml:"id"`
+	Title     string      `xml:"title"`
+	Link      atomLink    `xml:"link"`
+	Published string      `xml:"published"`
+	Updated   string      `xml:"updated"`
+	Summary   string      `xml:"summary,omitempty"`
+	Content   atomContent `xml:"content"`
+}
+
+type atomContent struct {
+	Type string `xml:"type,attr"`
+	Body string `xml:",chardata"`
+}
+
+type rssFeed struct {
+	XMLName xml.Name   `xml:"rss"`
+	Version string     `xml:"version,attr"`
+	Atom    string     `xml:"xmlns:atom,attr"`
+	Channel rssChannel `xml:"channel"`
+}
+
+type rssChannel struct {
+	Title         string    `xml:"title"`
+	Link          string    `xml:"link"`
+	Description   string    `xml:"description"`
+	LastBuildDate string    `xml:"lastBuildDate,omitempty"`
+	Self          atomLink  `xml:"atom:link"`
+	Items         []rssItem `xml:"item"`
+}
+
+type rssItem struct {
+	Title       string  `xml:"title"`
+	Link        string  `xml:"link"`
+	GUID        rssGUID `xml:"guid"`
+	PubDate     string  `xml:"pubDate"`
+	Description string  `xml:"description"`
+}
+
+type rssGUID struct {
+	IsPermaLink bool   `xml:"isPermaLink,attr"`
+	ID          string `xml:",chardata"`
+}
+
+// render encodes the feed as Atom or RSS 2.0
+func (f *feed) render(format string) ([]byte, error) {
+	var doc interface{}
+	switch format {
+	case feedFormatAtom:
+		atom := atomFeed{
+			ID:       f.ID,
+			Title:    f.Title,
+			Subtitle: f.Subtitle,
+			Updated:  f.Updated.UTC().Format(time.RFC3339),
+			Links: []atomLink{
+				{Href: f.Link, Rel: "alternate", Type: "text/html"},
+				{Href: f.Self, Rel: "self", Type: "application/atom+xml"},
+			},
+			Author: atomAuthor{Name: "CineSeer"},
+		}
+		for _, e := range f.Entries {
+			atom.Entries = append(atom.Entries, atomEntry{
+				ID:        e.ID,
+				Title:     e.Title,
+				Link:      atomLink{Href: e.Link, Rel: "alternate", Type: "text/html"},
+				Published: e.Published.UTC().Format(time.RFC3339),
+				Updated:   e.Updated.UTC().Format(time.RFC3339),
+				Summary:   e.Summary,
+				Content:   atomContent{Type: "html", Body: e.content()},
+			})
+		}
+		doc = atom
+	case feedFormatRSS:
+		rss := rssFeed{
+			Version: "2.0",
+			Atom:    "http://www.w3.org/2005/Atom",
+			Channel: rssChannel{
+				Title:       f.Title,
+				Link:        f.Link,
+				Description: f.Subtitle,
+				Self:        atomLink{Href: f.Self, Rel: "self", Type: "application/rss+xml"},
+			},
+		}
+		if !f.Updated.IsZero() {
+			rss.Channel.LastBuildDate = f.Updated.UTC().Format(time.RFC1123Z)
+		}
+		for _, e := range f.Entries {
+			rss.Channel.Items = append(rss.Channel.Items, rssItem{
+				Title:       e.Title,
+				Link:        e.Link,
+				GUID:        rssGUID{ID: e.ID},
+				PubDate:     e.Updated.UTC().Format(time.RFC1123Z),
+				Description: e.content(),
+			})
+		}
+		doc = rss
+	default:
+		return nil, fmt.Errorf("unknown feed format %q", format)
+	}
+
+	out, err := xml.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), out...), nil
+}
+
+// sendFeed writes a feed with validators, answering conditional requests
+// with 304 when the reader already has it
+func sendFeed(c *fiber.Ctx, f *feed, format string) error {
+	body, err := f.render(format)
+	if err != nil {
+		log.Printf("Error rendering feed %s: %v", c.Path(), err)
+		return c.Status(500).SendString("Could not render feed")
+	}
+
+	// Feeds carry no generation time, so the same content hashes the same
+	etag := fmt.Sprintf(`"%x"`, sha1.Sum(body))
+	c.Set(fiber.HeaderETag, etag)
+	if !f.Updated.IsZero() {
+		c.Set(fiber.HeaderLastModified, f.Updated.UTC().Format(http.TimeFormat))
+	}
+	c.Set(fiber.HeaderCacheControl, "public, max-age=900")
+	if notModified(c, etag, f.Updated) {
+		return c.SendStatus(fiber.StatusNotModified)
+	}
+
+	if format == feedFormatAtom {
+		c.Set(fiber.HeaderContentType, "application/atom+xml; charset=utf-8")
+	} else {
+		c.Set(fiber.HeaderContentType, "application/rss+xml; charset=utf-8")
+	}
+	return c.Send(body)
+}
+
+// notModified evaluates If-None-Match, or failing that If-Modified-Since
+// (RFC 9110 section 13.2.2)
+func notModified(c *fiber.Ctx, etag string, modified time.Time) bool {
+	if match := c.Get(fiber.HeaderIfNoneMatch); match != "" {
+		for _, tag := range strings.Split(match, ",") {
+			tag = strings.TrimPrefix(strings.TrimSpace(tag), "W/")
+			if tag == "*" || tag == etag {
+				return true
+			}
+		}
+		return false
+	}
+	if since := c.Get(fiber.HeaderIfModifiedSince); since != "" && !modified.IsZero() {
+		t, err := http.ParseTime(since)
+		return err == nil && !modified.Truncate(time.Second).After(t)
+	}
+	return false
+}
+
+// feedFormat reads the format from a feed URL's extension
+func feedFormat(c *fiber.Ctx) (string, bool) {
+	format := c.Params("format")
+	return format, format == feedFormatAtom || format == feedFormatRSS
+}
+
+// homeItemKey identifies a title within a home page section
+func homeItemKey(section string, item tmdb.MediaContent) string {
+	return fmt.Sprintf("%s/%d", section, item.ID)
+}
+
+// stampHomePage dates a new home page snapshot for feeds: a section is
+// updated when its lineup changes, and each title keeps the time it first
+// appeared in the section for as long as it stays there
+func stampHomePage(previous, next *HomePageData, now time.Time) {
+	next.Updated = make(map[string]time.Time, len(homeSections))
+	next.FirstSeen = make(map[string]time.Time)
+	for _, section := range homeSections {
+		items := *section.field(next)
+		next.Updated[section.key] = now
+		if previous != nil {
+			if updated, ok := previous.Updated[section.key]; ok && sameLineup(*section.field(previous), items) {
+				next.Updated[section.key] = updated
+			}
+		}
+		for _, item := range items {
+			key := homeItemKey(section.key, item)
+			next.FirstSeen[key] = now
+			if previous != nil {
+				if seen, ok := previous.FirstSeen[key]; ok {
+					next.FirstSeen[key] = seen
+				}
+			}
+		}
+	}
+}
+
+func sameLineup(a, b []tmdb.MediaContent) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i].ID != b[i].ID {
+			return false
+		}
+	}
+	return true
+}
+
+// homeSectionFeed lists a home page section, each title dated by when it
+// entered the section
+func homeSectionFeed(data *HomePageData, section homeSection, siteURL, self string) *feed {
+	f := &feed{
+		ID:       feedUUID("home/" + section.key),
+		Title:    "CineSeer: " + strings.ToUpper(section.name[:1]) + section.name[1:],
+		Subtitle: "Titles in the " + section.name + " row of the CineSeer home page",
+		Link:     siteURL + "/",
+		Self:     self,
+		Updated:  data.Updated[section.key],
+	}
+	for _, item := range *section.field(data) {
+		card, ok := mediaCardProps(item)
+		if !ok {
+			continue
+		}
+		title := card.Title
+		if card.Year != "" {
+			title += " (" + card.Year + ")"
+		}
+		seen := data.FirstSeen[homeItemKey(section.key, item)]
+		f.Entries = append(f.Entries, feedEntry{
+			ID:        feedUUID(fmt.Sprintf("home/%s/%s/%d", section.key, card.Type, card.ID)),
+			Title:     title,
+			Link:      fmt.Sprintf("%s/%s/%d", siteURL, card.Type, card.ID),
+			Summary:   card.Overview,
+			ImageURL:  fmt.Sprintf("%s%s?w=%d", siteURL, components.ImagePath(card.Type, card.ID, "poster"), components.CardPosterWidth),
+			Published: seen,
+			Updated:   seen,
+		})
+	}
+	f.finish()
+	return f
+}
+
+// episodeAnnouncements remembers when this server first saw each episode
+// before it aired, and under which air date, to date "announced" entries.
+// It lives in memory, so after a restart announcements are re-dated on the
+// next fetch. Past max announcements, those of episodes that aired over
+// announcementRetention ago are forgotten, then the oldest.
+var episodeAnnouncements = struct {
+	sync.Mutex
+	seen map[string]announcement
+	max  int
+}{seen: make(map[string]announcement), max: 10000}
+
+// By then feed readers have seen both of an episode's entries
+const announcementRetention = 30 * 24 * time.Hour
+
+type announcement struct {
+	At      time.Time
+	AirDate string
+}
+
+// announce records an unaired episode, re-dating it when its air date moves
+func announce(key, airDate string, now time.Time) {
+	episodeAnnouncements.Lock()
+	defer episodeAnnouncements.Unlock()
+	a, ok := episodeAnnouncements.seen[key]
+	if ok && a.AirDate == airDate {
+		return
+	}
+	if !ok && len(episodeAnnouncements.seen) >= episodeAnnouncements.max {
+		pruneAnnouncementsLocked(now)
+	}
+	episodeAnnouncements.seen[key] = announcement{At: now, AirDate: airDate}
+}
+
+// pruneAnnouncementsLocked makes room for one more announcement
+func pruneAnnouncementsLocked(now time.Time) {
+	seen := episodeAnnouncements.seen
+	for key, a := range seen {
+		if airDate, ok := tmdbDate(a.AirDate); ok && now.Sub(airDate) > announcementRetention {
+			delete(seen, key)
+		}
+	}
+	for len(seen) > 0 && len(seen) >= episodeAnnouncements.max {
+		var oldest string
+		for key, a := range seen {
+			if oldest == "" || a.At.Before(seen[oldest].At) {
+				oldest = key
+			}
+		}
+		delete(seen, oldest)
+	}
+}
+
+func announced(key string) (announcement, bool) {
+	episodeAnnouncements.Lock()
+	defer episodeAnnouncements.Unlock()
+	a, ok := episodeAnnouncements.seen[key]
+	return a, ok
+}
+
+// seriesFeed has an entry when an episode of the current or upcoming season
+// is announced and another when it airs
+func seriesFeed(series *tmdb.DetailedContent, seasons []*tmdb.SeasonDetails, siteURL, self string, now time.Time) *feed {
+	f := &feed{
+		ID:       feedUUID(fmt.Sprintf("series/%d", series.ID)),
+		Title:    "CineSeer: New episodes of " + series.Name,
+		Subtitle: series.Overview,
+		Link:     fmt.Sprintf("%s/series/%d", siteURL, series.ID),
+		Self:     self,
+	}
+	today := now.UTC().Truncate(24 * time.Hour)
+	for _, season := range seasons {
+		for _, episode := range season.Episodes {
+			number := fmt.Sprintf("S%02dE%02d", season.SeasonNumber, episode.EpisodeNumber)
+			name := fmt.Sprintf("%s %s", series.Name, number)
+			if episode.Name != "" {
+				name += ": " + episode.Name
+			}
+			key := fmt.Sprintf("series/%d/%s", series.ID, number)
+			entry := feedEntry{
+				Link:    siteURL + components.EpisodePath(series.ID, season.SeasonNumber, episode.EpisodeNumber),
+				Summary: episode.Overview,
+			}
+			if episode.StillPath != "" {
+				entry.ImageURL = fmt.Sprintf("%s/api/image/tv/%d/season/%d/episode/%d/still?w=300", siteURL, series.ID, season.SeasonNumber, episode.EpisodeNumber)
+			} else {
+				entry.ImageURL = fmt.Sprintf("%s%s?w=%d", siteURL, components.ImagePath("series", series.ID, "poster"), components.CardPosterWidth)
+			}
+
+			airDate, aired := tmdbDate(episode.AirDate)
+			aired = aired && !airDate.After(today)
+			if !aired {
+				announce(key, episode.AirDate, now)
+			}
+			if a, ok := announced(key); ok {
+				announcement := entry
+				announcement.ID = feedUUID(key + "/announced")
+				announcement.Title = name + " announced"
+				if date := formatDate(a.AirDate); date != "" {
+					announcement.Title += " for " + date
+				}
+				announcement.Published, announcement.Updated = a.At, a.At
+				f.Entries = append(f.Entries, announcement)
+			}
+			if aired {
+				entry.ID = feedUUID(key + "/aired")
+				entry.Title = name
+				entry.Published, entry.Updated = airDate, airDate
+				f.Entries = append(f.Entries, entry)
+			}
+		}
+	}
+	f.finish()
+	if f.Updated.IsZero() {
+		f.Updated, _ = tmdbDate(series.FirstAirDate)
+	}
+	return f
+}
+
+// setupFeeds serves Atom and RSS feeds of the home page sections at
+// /feeds/{section}.{atom|rss} and of a series' new episodes at
+// /feeds/series/{id}.{atom|rss}
+func setupFeeds(app *fiber.App, client *tmdb.Client, basePath string) {
+	app.Get(basePath+"/feeds/series/:id.:format", func(c *fiber.Ctx) error {
+		id, err := c.ParamsInt("id")
+		format, ok := feedFormat(c)
+		if err != nil || !ok {
+			return c.Status(404).SendString("Unknown feed")
+		}
+		series, err := client.SeriesDetails(c.Context(), id)
+		if err != nil {
+			return upstreamError(c, "series", err)
+		}
+
+		// The seasons of the last and next episodes hold everything new
+		var seasons []*tmdb.SeasonDetails
+		for _, episode := range []*tmdb.Episode{series.LastEpisodeToAir, series.NextEpisodeToAir} {
+			if episode == nil || (len(seasons) > 0 && seasons[0].SeasonNumber == episode.SeasonNumber) {
+				continue
+			}
+			season, err := client.SeasonDetails(c.Context(), id, episode.SeasonNumber)
+			if err != nil {
+				log.Printf("Error getting season %d of series %d for feed: %v", episode.SeasonNumber, id, err)
+				continue
+			}
+			season.SeasonNumber = episode.SeasonNumber
+			seasons = append(seasons, season)
+		}
+
+		siteURL := c.BaseURL() + basePath
+		return sendFeed(c, seriesFeed(series, seasons, siteURL, c.BaseURL()+c.Path(), time.Now()), format)
+	})
+
+	app.Get(basePath+"/feeds/:section.:format", func(c *fiber.Ctx) error {
+		format, ok := feedFormat(c)
+		if !ok {
+			return c.Status(404).SendString("Unknown feed")
+		}
+		var section *homeSection
+		for i := range homeSections {
+			if homeSections[i].key == c.Params("section") {
+				section = &homeSections[i]
+			}
+		}
+		if section == nil {
+			return c.Status(404).SendString("Unknown feed")
+		}
+
+		data, err := getHomePageData(c.Context(), client)
+		if err != nil {
+			return upstreamError(c, "feed", err)
+		}
+		siteURL := c.BaseURL() + basePath
+		return sendFeed(c, homeSectionFeed(data, *section, siteURL, c.BaseURL()+c.Path()), format)
+	})
+}
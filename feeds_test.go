@@ -0,0 +1,309 @@
+package main
+
+import (
+	"encoding/xml"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"cineseer/tmdb"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// newFeedTestApp serves feeds from a fixed home page snapshot. Nothing
+// should reach TMDB, so the client points at a server that always fails.
+func newFeedTestApp(t *testing.T) (*fiber.App, time.Time) {
+	t.Helper()
+	resetHomePage(t)
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("unexpected TMDB request for %s", r.URL.Path)
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+
+	updated := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	data := &HomePageData{
+		TrendingMovies: []tmdb.MediaContent{
+			{ID: 1, Title: "Alien", ReleaseDate: "1979-05-25", PosterPath: "/a.jpg", Overview: "In space, no one can hear you scream & <stuff>."},
+			{ID: 2, Title: "Aliens", ReleaseDate: "1986-07-18", PosterPath: "/b.jpg"},
+		},
+	}
+	stampHomePage(nil, data, updated)
+	homePageMutex.Lock()
+	homePageCache = data
+	homePageStaleAt = time.Now().Add(time.Hour)
+	homePageMutex.Unlock()
+
+	app := fiber.New()
+	setupFeeds(app, client, "")
+	return app, updated
+}
+
+func feedRequest(t *testing.T, app *fiber.App, path string, header ...string) (*http.Response, []byte) {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	for i := 0; i+1 < len(header); i += 2 {
+		req.Header.Set(header[i], header[i+1])
+	}
+	resp, err := app.Test(req, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resp, body
+}
+
+func TestAtomFeed(t *testing.T) {
+	app, updated := newFeedTestApp(t)
+	resp, body := feedRequest(t, app, "/feeds/trending_movies.atom")
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("status = %d: %s", resp.StatusCode, body)
+	}
+	if ct := resp.Header.Get("Content-Type"); ct != "application/atom+xml; charset=utf-8" {
+		t.Errorf("Content-Type = %q", ct)
+	}
+
+	var doc struct {
+		XMLName xml.Name `xml:"http://www.w3.org/2005/Atom feed"`
+		ID      string   `xml:"id"`
+		Title   string   `xml:"title"`
+		Updated string   `xml:"updated"`
+		Links   []struct {
+			Href string `xml:"href,attr"`
+			Rel  string `xml:"rel,attr"`
+		} `xml:"link"`
+		Author  string `xml:"author>name"`
+		Entries []struct {
+			ID      string `xml:"id"`
+			Title   string `xml:"title"`
+			Updated string `xml:"updated"`
+			Link    struct {
+				Href string `xml:"href,attr"`
+			} `xml:"link"`
+			Content struct {
+				Type string `xml:"type,attr"`
+				Body string `xml:",chardata"`
+			} `xml:"content"`
+		} `xml:"entry"`
+	}
+	if err := xml.Unmarshal(body, &doc); err != nil {
+		t.Fatalf("invalid Atom: %v\n%s", err, body)
+	}
+
+	// RFC 4287 requires id, title and updated on the feed and every entry,
+	// and an author on the feed when entries have none
+	if doc.ID == "" || doc.Title == "" || doc.Author == "" {
+		t.Errorf("feed missing id, title or author: %+v", doc)
+	}
+	if doc.Updated != updated.Format(time.RFC3339) {
+		t.Errorf("updated = %q, want %q", doc.Updated, updated.Format(time.RFC3339))
+	}
+	rels := map[string]string{}
+	for _, link := range doc.Links {
+		rels[link.Rel] = link.Href
+	}
+	if rels["self"] != "http://example.com/feeds/trending_movies.atom" || rels["alternate"] != "http://example.com/" {
+		t.Errorf("links = %v", rels)
+	}
+	if len(doc.Entries) != 2 {
+		t.Fatalf("got %d entries, want 2", len(doc.Entries))
+	}
+	ids := map[string]bool{}
+	for _, e := range doc.Entries {
+		if e.ID == "" || e.Title == "" || e.Link.Href == "" {
+			t.Errorf("entry missing id, title or link: %+v", e)
+		}
+		if _, err := time.Parse(time.RFC3339, e.Updated); err != nil {
+			t.Errorf("entry updated %q: %v", e.Updated, err)
+		}
+		if ids[e.ID] {
+			t.Errorf("duplicate entry id %s", e.ID)
+		}
+		ids[e.ID] = true
+	}
+	first := doc.Entries[0]
+	if first.Title != "Alien (1979)" || first.Link.Href != "http://example.com/movie/1" {
+		t.Errorf("first entry = %q linking to %q", first.Title, first.Link.Href)
+	}
+	// HTML content arrives escaped once, so readers get the markup back
+	if first.Content.Type != "html" || first.Content.Body != `<p><a href="http://example.com/movie/1"><img src="http://example.com/api/image/movie/1/poster?w=342" alt="Alien (1979)"/></a></p><p>In space, no one can hear you scream &amp; &lt;stuff&gt;.</p>` {
+		t.Errorf("content = %s %q", first.Content.Type, first.Content.Body)
+	}
+}
+
+func TestRSSFeed(t *testing.T) {
+	app, updated := newFeedTestApp(t)
+	resp, body := feedRequest(t, app, "/feeds/trending_movies.rss")
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("status = %d: %s", resp.StatusCode, body)
+	}
+	if ct := resp.Header.Get("Content-Type"); ct != "application/rss+xml; charset=utf-8" {
+		t.Errorf("Content-Type = %q", ct)
+	}
+
+	var doc struct {
+		XMLName xml.Name `xml:"rss"`
+		Version string   `xml:"version,attr"`
+		Channel struct {
+			Title         string `xml:"title"`
+			Description   string `xml:"description"`
+			LastBuildDate string `xml:"lastBuildDate"`
+			// The RSS link and the atom:link to the feed itself
+			Links []struct {
+				XMLName xml.Name
+				Href    string `xml:"href,attr"`
+				Rel     string `xml:"rel,attr"`
+				Value   string `xml:",chardata"`
+			} `xml:"link"`
+			Items []struct {
+				Title string `xml:"title"`
+				Link  string `xml:"link"`
+				GUID  struct {
+					IsPermaLink string `xml:"isPermaLink,attr"`
+					ID          string `xml:",chardata"`
+				} `xml:"guid"`
+				PubDate string `xml:"pubDate"`
+			} `xml:"item"`
+		} `xml:"channel"`
+	}
+	if err := xml.Unmarshal(body, &doc); err != nil {
+		t.Fatalf("invalid RSS: %v\n%s", err, body)
+	}
+
+	// RSS 2.0 requires title, link and description on the channel
+	ch := doc.Channel
+	if doc.Version != "2.0" || ch.Title == "" || ch.Description == "" {
+		t.Errorf("channel = %+v", ch)
+	}
+	var link, self string
+	for _, l := range ch.Links {
+		switch {
+		case l.XMLName.Space == "" && l.Value != "":
+			link = l.Value
+		case l.XMLName.Space == "http://www.w3.org/2005/Atom" && l.Rel == "self":
+			self = l.Href
+		}
+	}
+	if link != "http://example.com/" || self != "http://example.com/feeds/trending_movies.rss" {
+		t.Errorf("link = %q, atom:link = %q", link, self)
+	}
+	if built, err := time.Parse(time.RFC1123Z, ch.LastBuildDate); err != nil || !built.Equal(updated) {
+		t.Errorf("lastBuildDate = %q (%v), want %s", ch.LastBuildDate, err, updated)
+	}
+	if len(ch.Items) != 2 {
+		t.Fatalf("got %d items, want 2", len(ch.Items))
+	}
+	for _, item := range ch.Items {
+		if item.Title == "" || item.Link == "" {
+			t.Errorf("item missing title or link: %+v", item)
+		}
+		// GUIDs are URNs, not links
+		if item.GUID.IsPermaLink != "false" || item.GUID.ID == "" {
+			t.Errorf("guid = %+v", item.GUID)
+		}
+		if _, err := time.Parse(time.RFC1123Z, item.PubDate); err != nil {
+			t.Errorf("pubDate %q: %v", item.PubDate, err)
+		}
+	}
+}
+
+func TestFeedConditionalGet(t *testing.T) {
+	app, updated := newFeedTestApp(t)
+	resp, _ := feedRequest(t, app, "/feeds/trending_movies.atom")
+	etag := resp.Header.Get("ETag")
+	lastModified := resp.Header.Get("Last-Modified")
+	if etag == "" || lastModified != updated.Format(http.TimeFormat) {
+		t.Fatalf("ETag = %q, Last-Modified = %q", etag, lastModified)
+	}
+
+	tests := []struct {
+		name   string
+		header []string
+		want   int
+	}{
+		{"no validators", nil, http.StatusOK},
+		{"matching etag", []string{"If-None-Match", etag}, http.StatusNotModified},
+		{"weak etag", []string{"If-None-Match", "W/" + etag}, http.StatusNotModified},
+		{"etag in a list", []string{"If-None-Match", `"other", ` + etag}, http.StatusNotModified},
+		{"any etag", []string{"If-None-Match", "*"}, http.StatusNotModified},
+		{"stale etag", []string{"If-None-Match", `"other"`}, http.StatusOK},
+		{"same date", []string{"If-Modified-Since", lastModified}, http.StatusNotModified},
+		{"later date", []string{"If-Modified-Since", updated.Add(time.Hour).Format(http.TimeFormat)}, http.StatusNotModified},
+		{"earlier date", []string{"If-Modified-Since", updated.Add(-time.Hour).Format(http.TimeFormat)}, http.StatusOK},
+		{"bad date", []string{"If-Modified-Since", "yesterday"}, http.StatusOK},
+		// If-None-Match wins when both are sent
+		{"stale etag, same date", []string{"If-None-Match", `"other"`, "If-Modified-Since", lastModified}, http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, body := feedRequest(t, app, "/feeds/trending_movies.atom", tt.header...)
+			if resp.StatusCode != tt.want {
+				t.Fatalf("status = %d, want %d", resp.StatusCode, tt.want)
+			}
+			if tt.want == http.StatusNotModified && len(body) != 0 {
+				t.Errorf("304 with a %d byte body", len(body))
+			}
+			if resp.Header.Get("ETag") != etag {
+				t.Errorf("ETag = %q, want %q", resp.Header.Get("ETag"), etag)
+			}
+		})
+	}
+
+	// Each format is its own representation
+	if resp, _ := feedRequest(t, app, "/feeds/trending_movies.rss", "If-None-Match", etag); resp.StatusCode != http.StatusOK {
+		t.Errorf("RSS matched the Atom ETag: status %d", resp.StatusCode)
+	}
+}
+
+func TestUnknownFeeds(t *testing.T) {
+	app, _ := newFeedTestApp(t)
+	for _, path := range []string{"/feeds/nope.atom", "/feeds/trending_movies.json"} {
+		if resp, _ := feedRequest(t, app, path); resp.StatusCode != http.StatusNotFound {
+			t.Errorf("%s: status %d, want 404", path, resp.StatusCode)
+		}
+	}
+}
+
+func TestEpisodeAnnouncementsAreBounded(t *testing.T) {
+	episodeAnnouncements.Lock()
+	seen, max := episodeAnnouncements.seen, episodeAnnouncements.max
+	episodeAnnouncements.seen, episodeAnnouncements.max = make(map[string]announcement), 3
+	episodeAnnouncements.Unlock()
+	t.Cleanup(func() {
+		episodeAnnouncements.Lock()
+		episodeAnnouncements.seen, episodeAnnouncements.max = seen, max
+		episodeAnnouncements.Unlock()
+	})
+
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	announce("aired-long-ago", "2026-08-01", now.Add(-90*24*time.Hour))
+	announce("aired-recently", "2026-10-10", now.Add(-20*24*time.Hour))
+	announce("upcoming", "2026-11-01", now.Add(-10*24*time.Hour))
+
+	// Full: episodes long since aired go first
+	announce("new", "2026-12-01", now)
+	if _, ok := announced("aired-long-ago"); ok {
+		t.Error("announcement of an episode aired months ago kept")
+	}
+	// Re-announcing a known episode makes no room
+	announce("new", "2026-12-02", now.Add(time.Hour))
+	for _, key := range []string{"aired-recently", "upcoming", "new"} {
+		if _, ok := announced(key); !ok {
+			t.Errorf("%s forgotten", key)
+		}
+	}
+
+	// Then the oldest announcement
+	announce("newer", "2026-12-08", now)
+	if _, ok := announced("aired-recently"); ok {
+		t.Error("oldest announcement kept")
+	}
+	if n := len(episodeAnnouncements.seen); n != 3 {
+		t.Errorf("%d announcements kept, want 3", n)
+	}
+}
@@ -1,23 +1,22 @@
 package main
 
 import (
+	"context"
 	"fmt"
-	"github.com/gofiber/fiber/v2"
 	"log"
+	"net/url"
 	"os"
-	"path/filepath"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
 	"time"
+
 	"cineseer/components"
-)
+	"cineseer/tmdb"
 
-var (
-	cacheMutex       sync.Mutex
-	lastCacheTime    time.Time
-	cachedMediaIds   = make(map[int]bool)
-	cacheInProgress  bool
+	"github.com/a-h/templ"
+	"github.com/gofiber/fiber/v2"
 )
 
 func min(a, b int) int {
@@ -27,126 +26,11 @@ func min(a, b int) int {
 	return b
 }
 
-// cacheMediaContent handles caching all content for a single media item (movie or series)
-func cacheMediaContent(content MediaContent, wg *sync.WaitGroup) {
-	defer wg.Done()
-
-	// Check if this content has already been cached
-	cacheMutex.Lock()
-	if cachedMediaIds[content.ID] {
-		cacheMutex.Unlock()
-		return
-	}
-	cacheMutex.Unlock()
-
-	// Cache poster image
-	if content.PosterPath != "" {
-		err := cacheImage(content.PosterPath, fmt.Sprint(content.ID), "poster")
-		if err != nil {
-			log.Printf("Error caching poster for content %d: %v", content.ID, err)
-		}
-	}
-
-	// Cache backdrop image
-	if content.BackdropPath != "" {
-		err := cacheImage(content.BackdropPath, fmt.Sprint(content.ID), "backdrop")
-		if err != nil {
-			log.Printf("Error caching backdrop for content %d: %v", content.ID, err)
-		}
-	}
-
-	// Get and cache detailed info
-	var err error
-	if isMovie(content) {
-		_, err = get_details_movies(content.ID)
-	} else {
-		_, err = get_details_series(content.ID)
-	}
-	if err != nil {
-		log.Printf("Error caching content info for ID %d: %v", content.ID, err)
-	}
-
-	// Mark this content as cached
-	cacheMutex.Lock()
-	cachedMediaIds[content.ID] = true
-	cacheMutex.Unlock()
-
-	log.Printf("Cached all content for ID %d (%s)", content.ID, content.Title)
-}
-
-// startBackgroundCaching initiates the caching process for all series content
-func startBackgroundCaching() {
-	cacheMutex.Lock()
-	// Only cache if it's been more than 15 minutes since last cache and no cache is in progress
-	if !cacheInProgress && time.Since(lastCacheTime) > 15*time.Minute {
-		cacheInProgress = true
-		cacheMutex.Unlock()
-		
-		go func() {
-			defer func() {
-				cacheMutex.Lock()
-				cacheInProgress = false
-				lastCacheTime = time.Now()
-				cacheMutex.Unlock()
-			}()
-
-			log.Printf("Starting background caching process")
-			
-			// Get homepage data for caching
-			homePageData, err := getHomePageData()
-			if err != nil {
-				log.Printf("Error getting homepage data for caching: %v", err)
-				return
-			}
-
-			// Use a WaitGroup to track all goroutines
-			var wg sync.WaitGroup
-
-			// Cache trending TV shows
-			for _, content := range homePageData.TrendingTV {
-				wg.Add(1)
-				go cacheMediaContent(content, &wg)
-			}
-
-			// Cache trending movies
-			for _, content := range homePageData.TrendingMovies {
-				wg.Add(1)
-				go cacheMediaContent(content, &wg)
-			}
-
-			// Cache popular TV shows
-			for _, content := range homePageData.PopularTV {
-				wg.Add(1)
-				go cacheMediaContent(content, &wg)
-			}
-
-			// Cache popular movies
-			for _, content := range homePageData.PopularMovies {
-				wg.Add(1)
-				go cacheMediaContent(content, &wg)
-			}
-
-			// Cache upcoming movies
-			for _, content := range homePageData.UpcomingMovies {
-				wg.Add(1)
-				go cacheMediaContent(content, &wg)
-			}
-
-			// Wait for all caching operations to complete
-			wg.Wait()
-			log.Printf("Background caching process completed")
-		}()
-	} else {
-		cacheMutex.Unlock()
-		if cacheInProgress {
-			log.Printf("Background caching already in progress")
-		} else {
-			log.Printf("Skipping background cache, last cache was less than 15 minutes ago")
-		}
-	}
-}
-
-func setupFrontend(app *fiber.App) {
+// setupFrontend registers the pages and the API. Handlers give the TMDB
+// client c.Context(), which fasthttp only cancels when the server shuts down,
+// not when a visitor disconnects, so an aborted page load doesn't stop its
+// TMDB calls; they end with the client's timeout and retries.
+func setupFrontend(app *fiber.App, client *tmdb.Client) {
 	// Get base path from environment variable, default to "/"
 	basePath := os.Getenv("BASE_PATH")
 	if basePath == "" {
@@ -161,13 +45,14 @@ func setupFrontend(app *fiber.App) {
 	// Serve static files (including cached images)
 	app.Static(basePath+"/static", "./static")
 
-	// Main route serves the template and starts background caching
+	// Sign in, and resolve the user of every request after this
+	setupAuth(app, basePath)
+
+	// Main route serves the template; the background warmer keeps its
+	// content cached
 	app.Get(basePath+"/", func(c *fiber.Ctx) error {
 		log.Printf("Serving index page to %s", c.IP())
-		// Start background caching after serving the page
-		startBackgroundCaching()
-		c.Response().Header.Set("Content-Type", "text/html; charset=utf-8")
-		return components.Home().Render(c.Context(), c.Response().BodyWriter())
+		return render(c, basePath, components.Home())
 
 	})
 
@@ -179,12 +64,37 @@ func setupFrontend(app *fiber.App) {
 		if err != nil {
 			return c.Status(400).SendString("Invalid ID")
 		}
-		details, err := get_details_series(id)
+		details, err := client.SeriesDetails(c.Context(), id)
 		if err != nil {
-			return c.Status(500).SendString(err.Error())
+			return upstreamError(c, "series", err)
 		}
-		c.Response().Header.Set("Content-Type", "text/html; charset=utf-8")
-		return components.MediaDetail(detailedContentToProps(details)).Render(c.Context(), c.Response().BodyWriter())
+		return render(c, basePath, components.MediaDetail(detailedContentToProps(details, "series", uiLanguage(c))))
+	})
+
+	// Route to serve a single episode of a series
+	app.Get(basePath+"/series/:id/season/:season/episode/:episode", func(c *fiber.Ctx) error {
+		id, err := c.ParamsInt("id")
+		season, seasonErr := c.ParamsInt("season")
+		episode, episodeErr := c.ParamsInt("episode")
+		if err != nil || seasonErr != nil || episodeErr != nil {
+			return c.Status(400).SendString("Invalid episode")
+		}
+		log.Printf("Serving episode page for series %d S%dE%d to %s", id, season, episode, c.IP())
+		series, err := client.SeriesDetails(c.Context(), id)
+		if err != nil {
+			return upstreamError(c, "series", err)
+		}
+		seasonDetails, err := client.SeasonDetails(c.Context(), id, season)
+		if err != nil {
+			return upstreamError(c, "season", err)
+		}
+		details, err := client.EpisodeDetails(c.Context(), id, season, episode)
+		if err != nil {
+			return upstreamError(c, "episode", err)
+		}
+		props := episodePageProps(series, seasonDetails, details)
+		trackEpisode(c, &props)
+		return render(c, basePath, components.EpisodePage(props))
 	})
 
 	// Route to serve the movie detail page
@@ -195,17 +105,59 @@ func setupFrontend(app *fiber.App) {
 		if err != nil {
 			return c.Status(400).SendString("Invalid ID")
 		}
-		details, err := get_details_movies(id)
+		details, err := client.MovieDetails(c.Context(), id)
+		if err != nil {
+			return upstreamError(c, "movie", err)
+		}
+		return render(c, basePath, components.MediaDetail(detailedContentToProps(details, "movie", uiLanguage(c))))
+	})
+
+	// Route to serve a person's bio and filmography
+	app.Get(basePath+"/person/:id", func(c *fiber.Ctx) error {
+		id, err := c.ParamsInt("id")
+		if err != nil {
+			return c.Status(400).SendString("Invalid ID")
+		}
+		log.Printf("Serving person page for ID %d to %s", id, c.IP())
+		person, err := client.PersonDetails(c.Context(), id)
+		if err != nil {
+			return upstreamError(c, "person", err)
+		}
+		return render(c, basePath, components.PersonPage(personToProps(person)))
+	})
+
+	// Route to serve a collection with all of its movies
+	app.Get(basePath+"/collection/:id", func(c *fiber.Ctx) error {
+		id, err := c.ParamsInt("id")
+		if err != nil {
+			return c.Status(400).SendString("Invalid ID")
+		}
+		log.Printf("Serving collection page for ID %d to %s", id, c.IP())
+		collection, err := client.CollectionDetails(c.Context(), id)
 		if err != nil {
-			return c.Status(500).SendString(err.Error())
+			return upstreamError(c, "collection", err)
 		}
-		c.Response().Header.Set("Content-Type", "text/html; charset=utf-8")
-		return components.MediaDetail(detailedContentToProps(details)).Render(c.Context(), c.Response().BodyWriter())
+		return render(c, basePath, components.CollectionPage(collectionToProps(c.Context(), client, collection)))
 	})
 
+	// Subscribable feed of release dates and air dates
+	setupCalendarFeed(app, client, basePath)
+
+	// Atom and RSS feeds of the home page rows and of new episodes
+	setupFeeds(app, client, basePath)
+
 	// API routes
 	api := app.Group(basePath + "/api")
 
+	// Week and month calendar of releases and air dates
+	setupCalendarPage(app, api, client, basePath)
+
+	// Watchlist page and toggles
+	setupWatchlist(app, api, client, basePath)
+
+	// Next episodes of the series being watched
+	setupUpNext(app, client, basePath)
+
 	// Home page data endpoint with HTML rendering
 	api.Get("/home", func(c *fiber.Ctx) error {
 		mediaType := c.Query("type")
@@ -215,15 +167,62 @@ func setupFrontend(app *fiber.App) {
 			})
 		}
 
-		homeData, err := getHomePageData()
+		// The viewer's own rows, which aren't cached
+		if mediaType == "up_next" {
+			user := currentUser(c)
+			if user == nil {
+				return c.Status(401).SendString("<div class='error'>Sign in to see what's up next</div>")
+			}
+			entries, err := upNextRow(c.Context(), client, user.ID)
+			if err != nil {
+				log.Printf("Error getting up next for %s: %v", user.Username, err)
+				return c.Status(500).SendString("<div class='error'>Error loading up next</div>")
+			}
+			if len(entries) == 0 {
+				return c.SendString("<div class='error'>You're all caught up</div>")
+			}
+			return render(c, basePath, components.UpNextRow(entries))
+		}
+		if mediaType == "watchlist" {
+			user := currentUser(c)
+			if user == nil {
+				return c.Status(401).SendString("<div class='error'>Sign in to see your watchlist</div>")
+			}
+			cards, err := watchlistHomeRow(c.Context(), user.ID)
+			if err != nil {
+				log.Printf("Error getting watchlist of %s: %v", user.Username, err)
+				return c.Status(500).SendString("<div class='error'>Error loading your watchlist</div>")
+			}
+			if len(cards) == 0 {
+				return c.SendString("<div class='error'>Nothing on your watchlist yet</div>")
+			}
+			return render(c, basePath, components.MediaList(cards))
+		}
+
+		// Signed-in viewers get recommendations from their own watchlist,
+		// falling back to the shared rows until they have one
+		if user := currentUser(c); user != nil && (mediaType == "recommended_tv" || mediaType == "recommended_movies") {
+			kind := "series"
+			if mediaType == "recommended_movies" {
+				kind = "movie"
+			}
+			cards, err := personalRecommendations(c.Context(), client, user.ID, kind)
+			if err != nil {
+				log.Printf("Error getting recommendations for %s: %v", user.Username, err)
+			} else if len(cards) > 0 {
+				return render(c, basePath, components.MediaList(cards))
+			}
+		}
+
+		homeData, err := getHomePageData(c.Context(), client)
 		if err != nil {
 			log.Printf("Error getting home page data: %v", err)
-			return c.Status(500).JSON(fiber.Map{
-				"error": err.Error(),
+			return c.Status(502).JSON(fiber.Map{
+				"error": "Could not load this section from TMDB",
 			})
 		}
 
-		var items []MediaContent
+		var items []tmdb.MediaContent
 		switch mediaType {
 		case "trending_tv":
 			items = homeData.TrendingTV
@@ -250,31 +249,8 @@ func setupFrontend(app *fiber.App) {
 		// Build HTML for valid items
 		mediaCards := make([]components.MediaCardProps, 0)
 		for _, item := range items[:min(len(items), 20)] {
-			if item.Title == "" {
-				item.Title = item.Name
-			}
-			if item.Title != "" && item.PosterPath != "" {
-				var year string
-				var contentType string
-				if item.ReleaseDate != "" {
-					if t, err := time.Parse("2006-01-02", item.ReleaseDate); err == nil {
-						year = fmt.Sprint(t.Year())
-					}
-					contentType = "movie"
-				} else if item.FirstAirDate != "" {
-					if t, err := time.Parse("2006-01-02", item.FirstAirDate); err == nil {
-						year = fmt.Sprint(t.Year())
-					}
-					contentType = "series"
-				}
-
-				mediaCards = append(mediaCards, components.MediaCardProps{
-					ID:       item.ID,
-					Title:    item.Title,
-					Year:     year,
-					Overview: item.Overview,
-					Type:     contentType,
-				})
+			if card, ok := mediaCardProps(item); ok {
+				mediaCards = append(mediaCards, card)
 			}
 		}
 
@@ -282,63 +258,109 @@ func setupFrontend(app *fiber.App) {
 			return c.SendString("<div class='error'>No valid content available</div>")
 		}
 
-		return components.MediaList(mediaCards).Render(c.Context(), c.Response().BodyWriter())
+		return render(c, basePath, components.MediaList(mediaCards))
 	})
 
-	// Image endpoint
-	api.Get("/image/:id/:type", func(c *fiber.Ctx) error {
-		contentID := c.Params("id")
-		imgType := c.Params("type") // poster or backdrop
-		
-		if contentID == "" {
-			return c.Status(400).JSON(fiber.Map{
-				"error": "Content ID is required",
-			})
+	// Search page, rendered server-side so results can be bookmarked
+	app.Get(basePath+"/search", func(c *fiber.Ctx) error {
+		props, _, err := searchResults(c, client)
+		if err != nil {
+			return upstreamError(c, "search results", err)
+		}
+		return render(c, basePath, components.SearchPage(props))
+	})
+
+	// Discover page with faceted filters encoded in the URL
+	app.Get(basePath+"/discover", func(c *fiber.Ctx) error {
+		filters, filter, query := discoverFilters(c)
+
+		genres, err := client.Genres(c.Context(), filters.Type)
+		if err != nil {
+			return upstreamError(c, "genres", err)
+		}
+		results, err := discoverResults(c, client, filters.Type, filter, query)
+		if err != nil {
+			return upstreamError(c, "results", err)
 		}
 
-		// Use ID-based filename
-		cacheFilename := fmt.Sprintf("%s-%s.jpg", contentID, imgType)
-		cachePath := filepath.Join("static", "cache", cacheFilename)
+		props := components.DiscoverPageProps{
+			Filters: filters,
+			Genres:  make([]components.GenreOption, len(genres)),
+			Results: results,
+		}
+		for i, g := range genres {
+			props.Genres[i] = components.GenreOption{ID: g.ID, Name: g.Name}
+		}
+		return render(c, basePath, components.DiscoverPage(props))
+	})
 
-		// Check if image exists in cache
-		if _, err := os.Stat(cachePath); os.IsNotExist(err) {
-			// Get content details to get image path
-			var imagePath string
-			id, _ := strconv.Atoi(contentID)
+	// Search results as HTML fragments for htmx, or JSON when asked for
+	api.Get("/search", func(c *fiber.Ctx) error {
+		props, resp, err := searchResults(c, client)
+		if err != nil {
+			log.Printf("Error searching for %q: %v", c.Query("q"), err)
+			return c.Status(502).JSON(fiber.Map{
+				"error": "Could not load search results from TMDB",
+			})
+		}
 
-			// Try movie first
-			if details, err := get_details_movies(id); err == nil {
-				if imgType == "poster" {
-					imagePath = details.PosterPath
-				} else {
-					imagePath = details.BackdropPath
-				}
-			} else {
-				// Try series if movie fails
-				if details, err := get_details_series(id); err == nil {
-					if imgType == "poster" {
-						imagePath = details.PosterPath
-					} else {
-						imagePath = details.BackdropPath
-					}
-				} else {
-					return c.Status(404).JSON(fiber.Map{
-						"error": "Content not found",
-					})
-				}
+		if c.Accepts("text/html", "application/json") == "application/json" {
+			if resp == nil {
+				resp = &tmdb.Response{Page: 1, Results: []tmdb.MediaContent{}}
 			}
+			return c.JSON(fiber.Map{
+				"query":         props.Query,
+				"type":          props.Type,
+				"page":          resp.Page,
+				"total_pages":   resp.TotalPages,
+				"total_results": resp.TotalResults,
+				"results":       resp.Results,
+			})
+		}
 
-			// Download and cache the image
-			if err := cacheImage(imagePath, contentID, imgType); err != nil {
-				log.Printf("Error caching image: %v", err)
-				return c.Status(500).JSON(fiber.Map{
-					"error": "Failed to cache image",
-				})
-			}
+		if !props.Compact && props.Query != "" {
+			c.Set("HX-Push-Url", basePath+"/search?"+string(c.Request().URI().QueryString()))
 		}
+		return render(c, basePath, components.SearchResults(props))
+	})
+
+	// Discover results fragment for htmx filtering and paging
+	api.Get("/discover", func(c *fiber.Ctx) error {
+		filters, filter, query := discoverFilters(c)
+		results, err := discoverResults(c, client, filters.Type, filter, query)
+		if err != nil {
+			log.Printf("Error discovering %s: %v", filters.Type, err)
+			return c.Status(500).SendString("<div class='error'>Failed to load results</div>")
+		}
+
+		pageQuery := url.Values{}
+		for k, v := range query {
+			pageQuery[k] = v
+		}
+		if filter.Page > 1 {
+			pageQuery.Set("page", strconv.Itoa(filter.Page))
+		}
+		c.Set("HX-Push-Url", basePath+"/discover?"+pageQuery.Encode())
+		return render(c, basePath, components.DiscoverResults(results))
+	})
 
-		// Serve the cached image
-		return c.SendFile(cachePath)
+	// Image endpoints
+	setupImageRoutes(api, client)
+
+	// Versioned JSON API for other tools
+	setupAPIv1(api, client, basePath)
+
+	// OpenAPI spec and Swagger UI for the JSON API
+	setupOpenAPI(api, basePath)
+
+	// Progress of the background warmer and its last run
+	api.Get("/warmer/status", func(c *fiber.Ctx) error {
+		if backgroundWarmer == nil {
+			return c.JSON(fiber.Map{
+				"enabled": false,
+			})
+		}
+		return c.JSON(backgroundWarmer.Status())
 	})
 
 	// Content details with HTML rendering
@@ -350,11 +372,11 @@ func setupFrontend(app *fiber.App) {
 			})
 		}
 
-		details, err := get_details_series(id)
+		details, err := client.SeriesDetails(c.Context(), id)
 		if err != nil {
 			log.Printf("Error getting series details for ID %d: %v", id, err)
-			return c.Status(500).JSON(fiber.Map{
-				"error": err.Error(),
+			return c.Status(502).JSON(fiber.Map{
+				"error": "Could not load this series from TMDB",
 			})
 		}
 
@@ -369,11 +391,11 @@ func setupFrontend(app *fiber.App) {
 			})
 		}
 
-		details, err := get_details_movies(id)
+		details, err := client.MovieDetails(c.Context(), id)
 		if err != nil {
 			log.Printf("Error getting movie details for ID %d: %v", id, err)
-			return c.Status(500).JSON(fiber.Map{
-				"error": err.Error(),
+			return c.Status(502).JSON(fiber.Map{
+				"error": "Could not load this movie from TMDB",
 			})
 		}
 
@@ -396,35 +418,46 @@ func setupFrontend(app *fiber.App) {
 			})
 		}
 
-		details, err := get_season_details(id, season)
+		details, err := client.SeasonDetails(c.Context(), id, season)
 		if err != nil {
 			log.Printf("Error getting season details for series %d season %d: %v", id, season, err)
-			return c.Status(500).JSON(fiber.Map{
-				"error": err.Error(),
+			return c.Status(502).JSON(fiber.Map{
+				"error": "Could not load this season from TMDB",
 			})
 		}
 
 		seasonProps := components.SeasonProps{
+			SeriesID:     id,
 			SeasonNumber: season,
-			Episodes:    make([]components.Episode, len(details.Episodes)),
+			Name:         details.Name,
+			EpisodeCount: len(details.Episodes),
+			AirDate:      formatDate(details.AirDate),
+			HasPoster:    details.PosterPath != "",
+			Episodes:     make([]components.Episode, len(details.Episodes)),
 		}
 
 		for i, ep := range details.Episodes {
-			seasonProps.Episodes[i] = components.Episode{
-				EpisodeNumber: ep.EpisodeNumber,
-				Name:         ep.Name,
-				Overview:     ep.Overview,
-				AirDate:      ep.AirDate,
-				VoteAverage:  ep.VoteAverage,
-				VoteCount:    ep.VoteCount,
-			}
+			seasonProps.Episodes[i] = episodeProps(ep)
 		}
 
-		return components.Season(seasonProps).Render(c.Context(), c.Response().BodyWriter())
+		trackSeason(c, &seasonProps)
+		return render(c, basePath, components.SeasonEpisodes(seasonProps))
 	})
 }
 
-func detailedContentToProps(content *DetailedContent) components.DetailedContentProps {
+// uiLanguage returns the ISO 639-1 code of the visitor's preferred language
+func uiLanguage(c *fiber.Ctx) string {
+	header := c.Get(fiber.HeaderAcceptLanguage)
+	if len(header) >= 2 && header[0] != '*' {
+		return strings.ToLower(header[:2])
+	}
+	return "en"
+}
+
+// detailCrewJobs are the crew jobs the media detail page lists
+var detailCrewJobs = map[string]bool{"Director": true, "Screenplay": true, "Producer": true}
+
+func detailedContentToProps(content *tmdb.DetailedContent, contentType string, language string) components.DetailedContentProps {
 	// Get the title, preferring Title over Name
 	title := content.Title
 	if title == "" {
@@ -472,16 +505,29 @@ func detailedContentToProps(content *DetailedContent) components.DetailedContent
 		companies[i] = components.ProductionCompany{Name: c.Name}
 	}
 
-	// Convert credits
+	// Convert credits, keeping the top-billed cast. A series' crew is
+	// flattened from every episode, so only the jobs the page shows are kept.
 	credits := components.Credits{
-		Crew: make([]components.CrewMember, len(content.Credits.Crew)),
+		Cast: make([]components.CastMember, min(len(content.Credits.Cast), 12)),
+		Crew: make([]components.CrewMember, 0, len(content.Credits.Crew)),
 	}
-	for i, c := range content.Credits.Crew {
-		credits.Crew[i] = components.CrewMember{
-			Job:  c.Job,
+	for i, c := range content.Credits.Cast[:len(credits.Cast)] {
+		credits.Cast[i] = components.CastMember{
+			ID:   c.ID,
 			Name: c.Name,
+			Role: c.Role,
 		}
 	}
+	for _, c := range content.Credits.Crew {
+		if contentType == "series" && !detailCrewJobs[c.Job] {
+			continue
+		}
+		credits.Crew = append(credits.Crew, components.CrewMember{
+			ID:   c.ID,
+			Job:  c.Job,
+			Name: c.Name,
+		})
+	}
 
 	// Convert keywords
 	keywords := components.Keywords{
@@ -502,37 +548,507 @@ func detailedContentToProps(content *DetailedContent) components.DetailedContent
 	// Set backdrop path if it exists
 	backdropPath := ""
 	if content.BackdropPath != "" {
-		backdropPath = fmt.Sprintf("../api/image/%d/backdrop", content.ID)
+		backdropPath = components.ImagePath(contentType, content.ID, "backdrop")
+	}
+
+	// Pick the trailer behind the "Watch Trailer" button, if any
+	var trailer *components.Trailer
+	if video := content.Videos.BestTrailer(language); video != nil {
+		trailer = &components.Trailer{
+			Name:     video.Name,
+			EmbedURL: video.EmbedURL(),
+		}
 	}
 
 	return components.DetailedContentProps{
 		ID:                  content.ID,
-		Title:              title,
-		Year:               year,
-		Duration:           duration,
-		Status:             content.Status,
-		Genres:             genres,
-		Tagline:            content.Tagline,
-		Overview:           content.Overview,
-		Collection:         collection,
-		VoteAverage:        content.VoteAverage,
-		Popularity:         content.Popularity,
-		VoteCount:          content.VoteCount,
-		Revenue:            content.Revenue,
-		Budget:             content.Budget,
-		OriginalLanguage:   content.OriginalLanguage,
+		Type:                contentType,
+		Title:               title,
+		Year:                year,
+		Duration:            duration,
+		Status:              content.Status,
+		Genres:              genres,
+		Tagline:             content.Tagline,
+		Overview:            content.Overview,
+		Collection:          collection,
+		VoteAverage:         content.VoteAverage,
+		Popularity:          content.Popularity,
+		VoteCount:           content.VoteCount,
+		Revenue:             content.Revenue,
+		Budget:              content.Budget,
+		OriginalLanguage:    content.OriginalLanguage,
 		ProductionCountries: countries,
 		ProductionCompanies: companies,
-		Credits:            credits,
-		Keywords:           keywords,
-		BackdropPath:       backdropPath,
-		ReleaseDate:        releaseDate,
-		NumberOfSeasons:    content.NumberOfSeasons,
-		ID_str:             fmt.Sprint(content.ID),
+		Credits:             credits,
+		Keywords:            keywords,
+		BackdropPath:        backdropPath,
+		ReleaseDate:         releaseDate,
+		NumberOfSeasons:     content.NumberOfSeasons,
+		Seasons:             seasonList(content),
+		ID_str:              fmt.Sprint(content.ID),
+		Trailer:             trailer,
+	}
+}
+
+// seasonList lists a series' seasons in order, with specials last
+func seasonList(content *tmdb.DetailedContent) []components.SeasonProps {
+	seasons := make([]components.SeasonProps, 0, len(content.Seasons))
+	var specials []components.SeasonProps
+	for _, s := range content.Seasons {
+		season := components.SeasonProps{
+			SeriesID:     content.ID,
+			SeasonNumber: s.SeasonNumber,
+			Name:         s.Name,
+			EpisodeCount: s.EpisodeCount,
+			AirDate:      formatDate(s.AirDate),
+			HasPoster:    s.PosterPath != "",
+		}
+		if s.SeasonNumber == 0 {
+			specials = append(specials, season)
+			continue
+		}
+		seasons = append(seasons, season)
 	}
+	return append(seasons, specials...)
+}
+
+func episodeProps(ep tmdb.Episode) components.Episode {
+	episode := components.Episode{
+		EpisodeNumber: ep.EpisodeNumber,
+		Name:          ep.Name,
+		Overview:      ep.Overview,
+		AirDate:       formatDate(ep.AirDate),
+		VoteAverage:   ep.VoteAverage,
+		VoteCount:     ep.VoteCount,
+		HasStill:      ep.StillPath != "",
+	}
+	if ep.Runtime > 0 {
+		episode.Runtime = fmt.Sprintf("%d minutes", ep.Runtime)
+	}
+	return episode
+}
+
+// episodePageProps builds an episode page, linking to its neighbours in the
+// season
+func episodePageProps(series *tmdb.DetailedContent, season *tmdb.SeasonDetails, episode *tmdb.EpisodeDetails) components.EpisodePageProps {
+	ep := episodeProps(episode.Episode)
+	props := components.EpisodePageProps{
+		SeriesID:      series.ID,
+		SeriesName:    series.Name,
+		SeasonNumber:  episode.SeasonNumber,
+		SeasonName:    season.Name,
+		EpisodeNumber: episode.EpisodeNumber,
+		Name:          ep.Name,
+		Overview:      ep.Overview,
+		AirDate:       ep.AirDate,
+		Runtime:       ep.Runtime,
+		VoteAverage:   ep.VoteAverage,
+		VoteCount:     ep.VoteCount,
+		HasStill:      ep.HasStill,
+		StillCount:    len(episode.Images.Stills),
+	}
+
+	for _, star := range episode.GuestStars {
+		props.GuestStars = append(props.GuestStars, components.CastMember{ID: star.ID, Name: star.Name, Role: star.Role})
+	}
+
+	// Crew grouped by job, in the order TMDB first lists each job
+	jobs := make(map[string]int)
+	for _, member := range episode.Crew {
+		i, ok := jobs[member.Job]
+		if !ok {
+			i = len(props.Crew)
+			jobs[member.Job] = i
+			props.Crew = append(props.Crew, components.CrewGroup{Job: member.Job})
+		}
+		props.Crew[i].People = append(props.Crew[i].People, components.CrewMember{ID: member.ID, Job: member.Job, Name: member.Name})
+	}
+
+	for i, other := range season.Episodes {
+		if other.EpisodeNumber != episode.EpisodeNumber {
+			continue
+		}
+		if i > 0 {
+			prev := season.Episodes[i-1]
+			props.Prev = &components.EpisodeRef{SeasonNumber: props.SeasonNumber, EpisodeNumber: prev.EpisodeNumber, Name: prev.Name}
+		}
+		if i+1 < len(season.Episodes) {
+			next := season.Episodes[i+1]
+			props.Next = &components.EpisodeRef{SeasonNumber: props.SeasonNumber, EpisodeNumber: next.EpisodeNumber, Name: next.Name}
+		}
+		break
+	}
+	return props
+}
+
+func renderMediaContent(c *fiber.Ctx, content *tmdb.DetailedContent, contentType string, basePath string) error {
+	return render(c, basePath, components.MediaDetail(detailedContentToProps(content, contentType, uiLanguage(c))))
 }
 
-func renderMediaContent(c *fiber.Ctx, content *DetailedContent, contentType string, basePath string) error {
+// render writes an HTML component, making basePath available to its links
+// and the signed-in user to its header
+func render(c *fiber.Ctx, basePath string, component templ.Component) error {
 	c.Response().Header.Set("Content-Type", "text/html; charset=utf-8")
-	return components.MediaDetail(detailedContentToProps(content)).Render(c.Context(), c.Response().BodyWriter())
+	ctx := components.WithBasePath(c.Context(), basePath)
+	ctx = components.WithViewer(ctx, viewer(c))
+	return component.Render(ctx, c.Response().BodyWriter())
+}
+
+// upstreamError logs why TMDB data for a page couldn't be loaded and answers
+// with a generic 502; error details can quote TMDB request URLs
+func upstreamError(c *fiber.Ctx, what string, err error) error {
+	log.Printf("Error getting %s for %s: %v", what, c.Path(), err)
+	return c.Status(fiber.StatusBadGateway).SendString("Could not load " + what + " from TMDB")
+}
+
+// mediaCardProps converts a TMDB list item to a card, reporting false for
+// items that lack a title or poster
+func mediaCardProps(item tmdb.MediaContent) (components.MediaCardProps, bool) {
+	if item.Title == "" {
+		item.Title = item.Name
+	}
+	if item.Title == "" || item.PosterPath == "" {
+		return components.MediaCardProps{}, false
+	}
+
+	var year string
+	var contentType string
+	if item.MediaType == tmdb.MediaTypeMovie || (item.MediaType == "" && item.ReleaseDate != "") {
+		if t, err := time.Parse("2006-01-02", item.ReleaseDate); err == nil {
+			year = fmt.Sprint(t.Year())
+		}
+		contentType = "movie"
+	} else {
+		if t, err := time.Parse("2006-01-02", item.FirstAirDate); err == nil {
+			year = fmt.Sprint(t.Year())
+		}
+		contentType = "series"
+	}
+
+	return components.MediaCardProps{
+		ID:       item.ID,
+		Title:    item.Title,
+		Year:     year,
+		Overview: item.Overview,
+		Type:     contentType,
+	}, true
+}
+
+// searchResults runs the search described by the q, type and page query
+// parameters. The raw response is nil when there was nothing to search for.
+func searchResults(c *fiber.Ctx, client *tmdb.Client) (components.SearchResultsProps, *tmdb.Response, error) {
+	props := components.SearchResultsProps{
+		Query:   strings.TrimSpace(c.Query("q")),
+		Type:    c.Query("type"),
+		Page:    c.QueryInt("page", 1),
+		Compact: c.Query("compact") != "",
+	}
+	if props.Query == "" {
+		return props, nil, nil
+	}
+
+	resp, err := client.Search(c.Context(), props.Query, props.Type, props.Page)
+	if err != nil {
+		return props, nil, err
+	}
+	props.Page = resp.Page
+	props.TotalPages = resp.TotalPages
+	props.TotalResults = resp.TotalResults
+
+	for _, item := range resp.Results {
+		switch item.MediaType {
+		case tmdb.MediaTypeMovie, tmdb.MediaTypeTV:
+			if card, ok := mediaCardProps(item); ok {
+				props.Media = append(props.Media, card)
+			}
+		case tmdb.MediaTypePerson:
+			knownFor := make([]string, 0, len(item.KnownFor))
+			for _, k := range item.KnownFor {
+				if k.Title != "" {
+					knownFor = append(knownFor, k.Title)
+				} else if k.Name != "" {
+					knownFor = append(knownFor, k.Name)
+				}
+			}
+			props.People = append(props.People, components.PersonCardProps{
+				ID:         item.ID,
+				Name:       item.Name,
+				Department: item.KnownForDepartment,
+				KnownFor:   strings.Join(knownFor, ", "),
+			})
+		}
+	}
+	return props, resp, nil
+}
+
+// discoverFilters reads the discover facets from the query string. It
+// returns them as form values, as a TMDB filter, and as canonical query
+// parameters (without the page) for building shareable links.
+func discoverFilters(c *fiber.Ctx) (components.DiscoverFilters, tmdb.DiscoverFilter, url.Values) {
+	filters := components.DiscoverFilters{
+		Type:       c.Query("type", tmdb.MediaTypeMovie),
+		YearFrom:   c.Query("year_from"),
+		YearTo:     c.Query("year_to"),
+		MinRating:  c.Query("min_rating"),
+		MinVotes:   c.Query("min_votes"),
+		RuntimeMin: c.Query("runtime_min"),
+		RuntimeMax: c.Query("runtime_max"),
+		Language:   c.Query("language"),
+		Sort:       c.Query("sort", tmdb.SortPopularity),
+	}
+	if filters.Type != tmdb.MediaTypeTV {
+		filters.Type = tmdb.MediaTypeMovie
+	}
+	for _, v := range c.Context().QueryArgs().PeekMulti("genre") {
+		if id, err := strconv.Atoi(string(v)); err == nil {
+			filters.Genres = append(filters.Genres, id)
+		}
+	}
+
+	atoi := func(s string) int {
+		n, _ := strconv.Atoi(s)
+		return n
+	}
+	minRating, _ := strconv.ParseFloat(filters.MinRating, 64)
+	filter := tmdb.DiscoverFilter{
+		Genres:       filters.Genres,
+		YearFrom:     atoi(filters.YearFrom),
+		YearTo:       atoi(filters.YearTo),
+		MinRating:    minRating,
+		MinVoteCount: atoi(filters.MinVotes),
+		RuntimeMin:   atoi(filters.RuntimeMin),
+		RuntimeMax:   atoi(filters.RuntimeMax),
+		Language:     filters.Language,
+		Sort:         filters.Sort,
+		Page:         c.QueryInt("page", 1),
+	}
+
+	query := url.Values{"type": {filters.Type}, "sort": {filters.Sort}}
+	for _, id := range filters.Genres {
+		query.Add("genre", strconv.Itoa(id))
+	}
+	for key, value := range map[string]string{
+		"year_from":   filters.YearFrom,
+		"year_to":     filters.YearTo,
+		"min_rating":  filters.MinRating,
+		"min_votes":   filters.MinVotes,
+		"runtime_min": filters.RuntimeMin,
+		"runtime_max": filters.RuntimeMax,
+		"language":    filters.Language,
+	} {
+		if value != "" {
+			query.Set(key, value)
+		}
+	}
+	return filters, filter, query
+}
+
+// discoverResults fetches one page of discover results as cards
+func discoverResults(c *fiber.Ctx, client *tmdb.Client, mediaType string, filter tmdb.DiscoverFilter, query url.Values) (components.DiscoverResultsProps, error) {
+	resp, err := client.Discover(c.Context(), mediaType, filter)
+	if err != nil {
+		return components.DiscoverResultsProps{}, err
+	}
+
+	results := components.DiscoverResultsProps{
+		Page:         resp.Page,
+		TotalPages:   min(resp.TotalPages, 500), // TMDB refuses pages beyond 500
+		TotalResults: resp.TotalResults,
+		Query:        query,
+	}
+	for _, item := range resp.Results {
+		if card, ok := mediaCardProps(item); ok {
+			results.Items = append(results.Items, card)
+		}
+	}
+	return results, nil
+}
+
+func personToProps(person *tmdb.Person) components.PersonPageProps {
+	props := components.PersonPageProps{
+		ID:                 person.ID,
+		Name:               person.Name,
+		Biography:          person.Biography,
+		Birthday:           formatDate(person.Birthday),
+		Deathday:           formatDate(person.Deathday),
+		PlaceOfBirth:       person.PlaceOfBirth,
+		KnownForDepartment: person.KnownForDepartment,
+		HasProfile:         person.ProfilePath != "",
+	}
+
+	// Known for: the most voted-on titles across cast and crew credits
+	all := append(append([]tmdb.PersonCredit{}, person.CombinedCredits.Cast...), person.CombinedCredits.Crew...)
+	sort.SliceStable(all, func(i, j int) bool { return all[i].VoteCount > all[j].VoteCount })
+	seen := make(map[string]bool)
+	for _, credit := range all {
+		key := credit.MediaType + "/" + strconv.Itoa(credit.ID)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		card, ok := mediaCardProps(tmdb.MediaContent{
+			ID:           credit.ID,
+			Title:        credit.DisplayTitle(),
+			PosterPath:   credit.PosterPath,
+			ReleaseDate:  credit.ReleaseDate,
+			FirstAirDate: credit.FirstAirDate,
+			MediaType:    credit.MediaType,
+		})
+		if ok {
+			props.KnownFor = append(props.KnownFor, card)
+		}
+		if len(props.KnownFor) == 10 {
+			break
+		}
+	}
+
+	// Filmography grouped by department; a person's several jobs on the
+	// same title are merged into one row
+	groups := make(map[string][]tmdb.PersonCredit)
+	for _, credit := range person.CombinedCredits.Cast {
+		credit.Department = "Acting"
+		credit.Job = ""
+		if credit.Character != "" {
+			credit.Job = "as " + credit.Character
+		}
+		groups["Acting"] = append(groups["Acting"], credit)
+	}
+	for _, credit := range person.CombinedCredits.Crew {
+		groups[credit.Department] = append(groups[credit.Department], credit)
+	}
+
+	departments := make([]string, 0, len(groups))
+	for department := range groups {
+		departments = append(departments, department)
+	}
+	sort.Slice(departments, func(i, j int) bool {
+		a, b := departments[i], departments[j]
+		if (a == person.KnownForDepartment) != (b == person.KnownForDepartment) {
+			return a == person.KnownForDepartment
+		}
+		if len(groups[a]) != len(groups[b]) {
+			return len(groups[a]) > len(groups[b])
+		}
+		return a < b
+	})
+
+	for _, department := range departments {
+		credits := groups[department]
+		// Newest first; undated (usually announced) titles lead
+		sort.SliceStable(credits, func(i, j int) bool {
+			a, b := credits[i].Date(), credits[j].Date()
+			if (a == "") != (b == "") {
+				return a == ""
+			}
+			return a > b
+		})
+
+		group := components.FilmographyGroup{Department: department}
+		rows := make(map[string]int)
+		for _, credit := range credits {
+			key := credit.MediaType + "/" + strconv.Itoa(credit.ID)
+			if i, ok := rows[key]; ok {
+				if credit.Job != "" && !strings.Contains(group.Credits[i].Role, credit.Job) {
+					group.Credits[i].Role += ", " + credit.Job
+				}
+				continue
+			}
+			contentType := "movie"
+			if credit.MediaType == tmdb.MediaTypeTV {
+				contentType = "series"
+			}
+			rows[key] = len(group.Credits)
+			group.Credits = append(group.Credits, components.PersonCredit{
+				ID:    credit.ID,
+				Type:  contentType,
+				Title: credit.DisplayTitle(),
+				Role:  credit.Job,
+				Year:  yearOf(credit.Date()),
+			})
+		}
+		props.Filmography = append(props.Filmography, group)
+	}
+	return props
+}
+
+// formatDate turns a TMDB date into "January 2, 2006", or "" if unparseable
+func formatDate(date string) string {
+	t, err := time.Parse("2006-01-02", date)
+	if err != nil {
+		return ""
+	}
+	return t.Format("January 2, 2006")
+}
+
+// yearOf returns the year of a TMDB date, or "" if unparseable
+func yearOf(date string) string {
+	t, err := time.Parse("2006-01-02", date)
+	if err != nil {
+		return ""
+	}
+	return fmt.Sprint(t.Year())
+}
+
+// collectionToProps lists a collection's movies in release order. Runtimes
+// aren't part of the collection response, so each movie's details are
+// fetched (and cached) concurrently.
+func collectionToProps(ctx context.Context, client *tmdb.Client, collection *tmdb.CollectionDetails) components.CollectionPageProps {
+	parts := append([]tmdb.MediaContent{}, collection.Parts...)
+	sort.SliceStable(parts, func(i, j int) bool {
+		a, b := parts[i].ReleaseDate, parts[j].ReleaseDate
+		if (a == "") != (b == "") {
+			return b == ""
+		}
+		return a < b
+	})
+
+	runtimes := make([]int, len(parts))
+	var wg sync.WaitGroup
+	for i, part := range parts {
+		wg.Add(1)
+		go func(i int, movieID int) {
+			defer wg.Done()
+			details, err := client.MovieDetails(ctx, movieID)
+			if err != nil {
+				log.Printf("Error getting runtime for movie %d: %v", movieID, err)
+				return
+			}
+			runtimes[i] = details.Runtime
+		}(i, part.ID)
+	}
+	wg.Wait()
+
+	props := components.CollectionPageProps{
+		ID:          collection.ID,
+		Name:        collection.Name,
+		Overview:    collection.Overview,
+		HasBackdrop: collection.BackdropPath != "",
+		Parts:       make([]components.CollectionPart, len(parts)),
+	}
+	total := 0
+	for i, part := range parts {
+		total += runtimes[i]
+		props.Parts[i] = components.CollectionPart{
+			ID:          part.ID,
+			Title:       part.Title,
+			Year:        yearOf(part.ReleaseDate),
+			ReleaseDate: formatDate(part.ReleaseDate),
+			Runtime:     formatRuntime(runtimes[i]),
+			Overview:    part.Overview,
+			VoteAverage: part.VoteAverage,
+		}
+	}
+	props.TotalRuntime = formatRuntime(total)
+	return props
+}
+
+// formatRuntime renders minutes as "2h 15m", or "" when unknown
+func formatRuntime(minutes int) string {
+	if minutes <= 0 {
+		return ""
+	}
+	if minutes < 60 {
+		return fmt.Sprintf("%dm", minutes)
+	}
+	return fmt.Sprintf("%dh %dm", minutes/60, minutes%60)
 }
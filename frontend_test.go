@@ -0,0 +1,81 @@
+package main
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"cineseer/tmdb"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestUpstreamErrorsHideDetails(t *testing.T) {
+	resetHomePage(t)
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		// Drop the connection, so the client's error quotes the request URL
+		if conn, _, err := w.(http.Hijacker).Hijack(); err == nil {
+			conn.Close()
+		}
+	})
+	app := fiber.New()
+	setupFrontend(app, client)
+
+	for _, path := range []string{
+		"/movie/1",
+		"/series/1",
+		"/series/1/season/1/episode/1",
+		"/person/1",
+		"/collection/1",
+		"/search?q=alien",
+		"/discover",
+		"/api/home?type=recommended_tv",
+		"/api/search?q=alien",
+		"/api/content/movie/1",
+		"/api/content/series/1/season/1",
+	} {
+		resp, err := app.Test(httptest.NewRequest(http.MethodGet, path, nil), -1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		if resp.StatusCode != http.StatusBadGateway {
+			t.Errorf("%s: status %d, want 502", path, resp.StatusCode)
+		}
+		if strings.Contains(string(body), "test-key") || strings.Contains(string(body), "api_key") {
+			t.Errorf("%s: body leaks request details: %s", path, body)
+		}
+	}
+}
+
+func TestDetailedContentToPropsCrew(t *testing.T) {
+	crew := []tmdb.CrewMember{
+		{ID: 1, Name: "Tony Gilroy", Job: "Screenplay"},
+		{ID: 2, Name: "Toby Haynes", Job: "Director"},
+		{ID: 3, Name: "Kathleen Kennedy", Job: "Producer"},
+		{ID: 4, Name: "Nicholas Britell", Job: "Original Music Composer"},
+		{ID: 5, Name: "A Grip", Job: "Key Grip"},
+	}
+	tests := []struct {
+		contentType string
+		want        []int
+	}{
+		{"series", []int{1, 2, 3}},
+		{"movie", []int{1, 2, 3, 4, 5}},
+	}
+	for _, tt := range tests {
+		content := &tmdb.DetailedContent{}
+		content.Credits.Crew = crew
+		props := detailedContentToProps(content, tt.contentType, "en")
+		got := make([]int, len(props.Credits.Crew))
+		for i, member := range props.Credits.Crew {
+			got[i] = member.ID
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s crew = %v, want %v", tt.contentType, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// icalMaxLine is the longest a content line may be, in octets, before it
+// must be folded (RFC 5545 section 3.1)
+const icalMaxLine = 75
+
+// icalWriter builds an RFC 5545 iCalendar stream, folding long lines and
+// ending each with CRLF
+type icalWriter struct {
+	buf bytes.Buffer
+}
+
+// prop writes a property whose value is already in iCalendar form
+func (w *icalWriter) prop(name, value string) {
+	line := name + ":" + value
+	limit := icalMaxLine
+	for len(line) > limit {
+		// Fold before the limit without splitting a UTF-8 sequence
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(line[cut]) {
+			cut--
+		}
+		w.buf.WriteString(line[:cut])
+		w.buf.WriteString("\r\n ")
+		line = line[cut:]
+		// The leading space counts towards continuation lines
+		limit = icalMaxLine - 1
+	}
+	w.buf.WriteString(line)
+	w.buf.WriteString("\r\n")
+}
+
+// text writes a TEXT property, escaping its value
+func (w *icalWriter) text(name, value string) {
+	w.prop(name, icalEscaper.Replace(value))
+}
+
+// date writes an all-day DATE property
+func (w *icalWriter) date(name string, t time.Time) {
+	w.prop(name+";VALUE=DATE", t.Format("20060102"))
+}
+
+// timestamp writes a DATE-TIME property in UTC
+func (w *icalWriter) timestamp(name string, t time.Time) {
+	w.prop(name, t.UTC().Format("20060102T150405Z"))
+}
+
+func (w *icalWriter) Bytes() []byte {
+	return w.buf.Bytes()
+}
+
+var icalEscaper = strings.NewReplacer(
+	`\`, `\\`,
+	";", `\;`,
+	",", `\,`,
+	"\r\n", `\n`,
+	"\n", `\n`,
+	"\r", "",
+)
@@ -0,0 +1,165 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+)
+
+// unfold reverses RFC 5545 line folding, checking every physical line on
+// the way
+func unfold(t *testing.T, out string) []string {
+	t.Helper()
+	if !strings.HasSuffix(out, "\r\n") {
+		t.Fatalf("output doesn't end with CRLF: %q", out)
+	}
+	var lines []string
+	for _, physical := range strings.Split(strings.TrimSuffix(out, "\r\n"), "\r\n") {
+		if len(physical) > icalMaxLine {
+			t.Errorf("line of %d octets: %q", len(physical), physical)
+		}
+		if !utf8.ValidString(physical) {
+			t.Errorf("line splits a UTF-8 sequence: %q", physical)
+		}
+		if strings.ContainsAny(physical, "\r\n") {
+			t.Errorf("bare CR or LF in line: %q", physical)
+		}
+		if strings.HasPrefix(physical, " ") {
+			if len(lines) == 0 {
+				t.Fatalf("output starts with a continuation line: %q", physical)
+			}
+			lines[len(lines)-1] += physical[1:]
+			continue
+		}
+		lines = append(lines, physical)
+	}
+	return lines
+}
+
+func TestICalFolding(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+		want  string // exact output, when it matters
+	}{
+		{"short", "Alien", "SUMMARY:Alien\r\n"},
+		{"exactly 75 octets", strings.Repeat("a", 67), "SUMMARY:" + strings.Repeat("a", 67) + "\r\n"},
+		{"76 octets", strings.Repeat("a", 68), "SUMMARY:" + strings.Repeat("a", 67) + "\r\n a\r\n"},
+		{"continuations hold 74 octets", strings.Repeat("a", 67+74+1),
+			"SUMMARY:" + strings.Repeat("a", 67) + "\r\n " + strings.Repeat("a", 74) + "\r\n a\r\n"},
+		// "é" is two octets and would straddle the 75th
+		{"two-octet rune at the limit", strings.Repeat("a", 66) + "é",
+			"SUMMARY:" + strings.Repeat("a", 66) + "\r\n é\r\n"},
+		{"three-octet runes", strings.Repeat("—", 60), ""},
+		{"four-octet runes", strings.Repeat("🎬", 50), ""},
+		{"mixed", strings.Repeat("Ünïcödé, 映画 and 🍿; ", 10), ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var w icalWriter
+			w.prop("SUMMARY", tt.value)
+			out := string(w.Bytes())
+			if tt.want != "" && out != tt.want {
+				t.Errorf("got  %q\nwant %q", out, tt.want)
+			}
+			lines := unfold(t, out)
+			if len(lines) != 1 || lines[0] != "SUMMARY:"+tt.value {
+				t.Errorf("unfolded to %q", lines)
+			}
+		})
+	}
+}
+
+func TestICalText(t *testing.T) {
+	tests := []struct {
+		value string
+		want  string
+	}{
+		{"Alien", `Alien`},
+		{"Andor S01E02: Rix Road", `Andor S01E02: Rix Road`},
+		{"Crime, Drama; Thriller", `Crime\, Drama\; Thriller`},
+		{`C:\Films`, `C:\\Films`},
+		{"One\nTwo\r\nThree\rFour", `One\nTwo\nThreeFour`},
+	}
+	for _, tt := range tests {
+		var w icalWriter
+		w.text("DESCRIPTION", tt.value)
+		if got, want := string(w.Bytes()), "DESCRIPTION:"+tt.want+"\r\n"; got != want {
+			t.Errorf("text(%q) = %q, want %q", tt.value, got, want)
+		}
+	}
+}
+
+func TestICalDates(t *testing.T) {
+	est := time.FixedZone("EST", -5*60*60)
+	var w icalWriter
+	w.date("DTSTART", time.Date(2027, 1, 5, 0, 0, 0, 0, time.UTC))
+	w.timestamp("DTSTAMP", time.Date(2026, 10, 16, 21, 30, 5, 0, est))
+
+	want := "DTSTART;VALUE=DATE:20270105\r\n" +
+		"DTSTAMP:20261017T023005Z\r\n"
+	if got := string(w.Bytes()); got != want {
+		t.Errorf("got  %q\nwant %q", got, want)
+	}
+}
+
+func TestWriteICalendar(t *testing.T) {
+	events := []calendarEvent{
+		{
+			UID:         "movie-1-us-3@cineseer",
+			Date:        time.Date(2027, 5, 1, 0, 0, 0, 0, time.UTC),
+			Title:       "Alien: In theaters",
+			Description: "In space, no one can hear you scream.",
+			Path:        "/movie/1",
+			Kind:        "movie",
+		},
+		{
+			UID:   "episode-1-1-2@cineseer",
+			Date:  time.Date(2027, 12, 31, 0, 0, 0, 0, time.UTC),
+			Title: "Andor S01E02: Rix Road, Part 1; Ferrix",
+			Path:  "/series/1/season/1/episode/2",
+			Kind:  "series",
+		},
+	}
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+
+	want := strings.Join([]string{
+		"BEGIN:VCALENDAR",
+		"VERSION:2.0",
+		"PRODID:-//CineSeer//Release Calendar//EN",
+		"CALSCALE:GREGORIAN",
+		"METHOD:PUBLISH",
+		"X-WR-CALNAME:CineSeer\\, releases",
+		"REFRESH-INTERVAL;VALUE=DURATION:PT6H",
+		"X-PUBLISHED-TTL:PT6H",
+		"BEGIN:VEVENT",
+		"UID:movie-1-us-3@cineseer",
+		"DTSTAMP:20261016T120000Z",
+		"DTSTART;VALUE=DATE:20270501",
+		"DTEND;VALUE=DATE:20270502",
+		"SUMMARY:Alien: In theaters",
+		"DESCRIPTION:In space\\, no one can hear you scream.",
+		"URL:https://example.com/movie/1",
+		"CATEGORIES:Movie",
+		"TRANSP:TRANSPARENT",
+		"END:VEVENT",
+		"BEGIN:VEVENT",
+		"UID:episode-1-1-2@cineseer",
+		"DTSTAMP:20261016T120000Z",
+		"DTSTART;VALUE=DATE:20271231",
+		"DTEND;VALUE=DATE:20280101",
+		"SUMMARY:Andor S01E02: Rix Road\\, Part 1\\; Ferrix",
+		"URL:https://example.com/series/1/season/1/episode/2",
+		"CATEGORIES:TV",
+		"TRANSP:TRANSPARENT",
+		"END:VEVENT",
+		"END:VCALENDAR",
+	}, "\r\n") + "\r\n"
+
+	got := string(writeICalendar("CineSeer, releases", "https://example.com", events, now))
+	if got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+	unfold(t, got)
+}
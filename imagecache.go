@@ -0,0 +1,229 @@
+package main
+
+import (
+	"container/list"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Defaults for the image cache, overridable with IMAGE_CACHE_MAX_MB and
+// IMAGE_CACHE_TTL
+const (
+	defaultImageCacheMaxBytes = 1 << 30
+	defaultImageCacheTTL      = 30 * 24 * time.Hour
+)
+
+// tempImagePrefix marks files still being written. They are never indexed
+// and are removed by the startup scan.
+const tempImagePrefix = ".tmp-"
+
+// imageCacheName matches the names imageKey.filename gives cached images
+var imageCacheName = regexp.MustCompile(`^[a-z]+-[0-9-]+-[a-z]+(-w[0-9]+)?\.(jpg|webp|avif)$`)
+
+// imageStore is the on-disk image cache, opened in main
+var imageStore *imageCache
+
+// imageCache is a size-bounded directory of cached images. Files are written
+// atomically, evicted least recently used first once the directory grows past
+// maxBytes, and reported stale after ttl so callers can fetch them again.
+type imageCache struct {
+	dir      string
+	maxBytes int64
+	ttl      time.Duration
+
+	mu      sync.Mutex
+	entries map[string]*list.Element // filename -> element holding *imageCacheEntry
+	lru     *list.List               // most recently used at the front
+	size    int64
+}
+
+type imageCacheEntry struct {
+	name   string
+	size   int64
+	stored time.Time
+}
+
+// newImageCache creates dir if needed and indexes the files already in it.
+// A maxBytes or ttl of zero disables that limit.
+func newImageCache(dir string, maxBytes int64, ttl time.Duration) (*imageCache, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	c := &imageCache{
+		dir:      dir,
+		maxBytes: maxBytes,
+		ttl:      ttl,
+		entries:  make(map[string]*list.Element),
+		lru:      list.New(),
+	}
+	if err := c.scan(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// scan rebuilds the index from disk. Access times aren't tracked across
+// restarts, so files start out ordered by when they were written.
+func (c *imageCache) scan() error {
+	dirEntries, err := os.ReadDir(c.dir)
+	if err != nil {
+		return err
+	}
+
+	var found []imageCacheEntry
+	for _, de := range dirEntries {
+		if de.IsDir() {
+			continue
+		}
+		if strings.HasPrefix(de.Name(), tempImagePrefix) {
+			os.Remove(filepath.Join(c.dir, de.Name()))
+			continue
+		}
+		// Leave anything else that isn't a cached image alone, like .gitkeep
+		if !imageCacheName.MatchString(de.Name()) {
+			continue
+		}
+		info, err := de.Info()
+		if err != nil {
+			continue
+		}
+		if info.Size() == 0 {
+			os.Remove(filepath.Join(c.dir, de.Name()))
+			continue
+		}
+		found = append(found, imageCacheEntry{name: de.Name(), size: info.Size(), stored: info.ModTime()})
+	}
+	sort.Slice(found, func(i, j int) bool {
+		return found[i].stored.After(found[j].stored)
+	})
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for i := range found {
+		e := found[i]
+		c.entries[e.name] = c.lru.PushBack(&e)
+		c.size += e.size
+	}
+	evicted := c.evictLocked("")
+	log.Printf("Image cache: indexed %d files (%d MB) in %s, evicted %d", len(found), c.size>>20, c.dir, evicted)
+	return nil
+}
+
+// path returns where name is stored, whether or not it exists
+func (c *imageCache) path(name string) string {
+	return filepath.Join(c.dir, name)
+}
+
+// Lookup returns the path of a cached image and whether it is still within
+// its TTL, marking it as recently used
+func (c *imageCache) Lookup(name string) (path string, fresh bool, ok bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	elem, ok := c.entries[name]
+	if !ok {
+		return "", false, false
+	}
+	c.lru.MoveToFront(elem)
+	e := elem.Value.(*imageCacheEntry)
+	return c.path(name), c.fresh(e), true
+}
+
+// Fresh reports whether name is cached and within its TTL without counting
+// as a use
+func (c *imageCache) Fresh(name string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	elem, ok := c.entries[name]
+	return ok && c.fresh(elem.Value.(*imageCacheEntry))
+}
+
+func (c *imageCache) fresh(e *imageCacheEntry) bool {
+	return c.ttl <= 0 || time.Since(e.stored) < c.ttl
+}
+
+// Forget drops name from the index if its file is no longer on disk, so the
+// next Lookup misses instead of pointing at nothing
+func (c *imageCache) Forget(name string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	elem, ok := c.entries[name]
+	if !ok {
+		return
+	}
+	if _, err := os.Stat(c.path(name)); !os.IsNotExist(err) {
+		return
+	}
+	c.size -= elem.Value.(*imageCacheEntry).size
+	c.lru.Remove(elem)
+	delete(c.entries, name)
+}
+
+// Store writes an image through write into a temp file and renames it into
+// place, so readers never see a partial file. On error nothing is stored and
+// any previous version is kept.
+func (c *imageCache) Store(name string, write func(w io.Writer) error) error {
+	tmp, err := os.CreateTemp(c.dir, tempImagePrefix+"*")
+	if err != nil {
+		return err
+	}
+	if err := write(tmp); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	info, err := os.Stat(tmp.Name())
+	if err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := os.Rename(tmp.Name(), c.path(name)); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if elem, ok := c.entries[name]; ok {
+		c.size -= elem.Value.(*imageCacheEntry).size
+		c.lru.Remove(elem)
+	}
+	c.entries[name] = c.lru.PushFront(&imageCacheEntry{name: name, size: info.Size(), stored: time.Now()})
+	c.size += info.Size()
+	c.evictLocked(name)
+	return nil
+}
+
+// evictLocked removes least recently used files until the cache fits in
+// maxBytes, never removing keep. It returns how many files were removed.
+func (c *imageCache) evictLocked(keep string) int {
+	if c.maxBytes <= 0 {
+		return 0
+	}
+	evicted := 0
+	for elem := c.lru.Back(); elem != nil && c.size > c.maxBytes; {
+		prev := elem.Prev()
+		e := elem.Value.(*imageCacheEntry)
+		if e.name != keep {
+			if err := os.Remove(c.path(e.name)); err != nil && !os.IsNotExist(err) {
+				log.Printf("Error evicting cached image %s: %v", e.name, err)
+			}
+			c.lru.Remove(elem)
+			delete(c.entries, e.name)
+			c.size -= e.size
+			evicted++
+		}
+		elem = prev
+	}
+	return evicted
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestImageCacheNameMatchesKeys(t *testing.T) {
+	keys := []imageKey{
+		{MediaType: "movie", ID: "550", Type: "poster"},
+		{MediaType: "tv", ID: "1399", Type: "backdrop", Width: 780, Format: formatWebP},
+		{MediaType: "person", ID: "287", Type: "profile", Format: formatAVIF},
+		{MediaType: "season", ID: "1399-2", Type: "poster", Width: 185},
+		{MediaType: "episode", ID: "1399-2-3-0", Type: "still", Width: 300, Format: formatJPEG},
+	}
+	for _, key := range keys {
+		if name := key.filename(); !imageCacheName.MatchString(name) {
+			t.Errorf("cache name pattern rejects %q", name)
+		}
+	}
+}
+
+func TestImageCacheScan(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		".gitkeep":                 "",
+		"notes.txt":                "",
+		tempImagePrefix + "123":    "partial",
+		"movie-1-poster.jpg":       "jpeg",
+		"tv-2-backdrop-w780.webp":  "webp",
+		"movie-3-poster-w185.avif": "",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	c, err := newImageCache(dir, 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{".gitkeep", "notes.txt", "movie-1-poster.jpg", "tv-2-backdrop-w780.webp"} {
+		if _, err := os.Stat(filepath.Join(dir, name)); err != nil {
+			t.Errorf("scan removed %s: %v", name, err)
+		}
+	}
+	for _, name := range []string{tempImagePrefix + "123", "movie-3-poster-w185.avif"} {
+		if _, err := os.Stat(filepath.Join(dir, name)); !os.IsNotExist(err) {
+			t.Errorf("scan kept %s", name)
+		}
+	}
+	if len(c.entries) != 2 || c.size != int64(len("jpeg")+len("webp")) {
+		t.Errorf("indexed %d files of %d bytes, want 2 of 8", len(c.entries), c.size)
+	}
+}
+
+func TestImageCacheEvictsLeastRecentlyUsed(t *testing.T) {
+	c, err := newImageCache(t.TempDir(), 10, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	store := func(name string) {
+		t.Helper()
+		err := c.Store(name, func(w io.Writer) error {
+			_, err := io.WriteString(w, strings.Repeat("x", 4))
+			return err
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	store("movie-1-poster.jpg")
+	store("movie-2-poster.jpg")
+	c.Lookup("movie-1-poster.jpg")
+	store("movie-3-poster.jpg")
+
+	if _, _, ok := c.Lookup("movie-2-poster.jpg"); ok {
+		t.Error("least recently used image survived eviction")
+	}
+	for _, name := range []string{"movie-1-poster.jpg", "movie-3-poster.jpg"} {
+		if _, _, ok := c.Lookup(name); !ok {
+			t.Errorf("%s was evicted", name)
+		}
+	}
+}
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"image"
+	"image/jpeg"
+	_ "image/png"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/gen2brain/avif"
+	"github.com/gen2brain/webp"
+	"golang.org/x/image/draw"
+)
+
+// Image formats the proxy can serve. JPEG is what TMDB stores and is always
+// available; the others are encoded locally.
+const (
+	formatJPEG = "jpeg"
+	formatWebP = "webp"
+	formatAVIF = "avif"
+)
+
+// imageFormats lists the modern formats offered to browsers, best first.
+// Set from IMAGE_FORMATS.
+var imageFormats = []string{formatAVIF, formatWebP}
+
+// parseImageFormats reads a comma-separated IMAGE_FORMATS value, keeping
+// only formats the proxy can encode
+func parseImageFormats(value string) []string {
+	var formats []string
+	for _, f := range strings.Split(value, ",") {
+		switch f = strings.ToLower(strings.TrimSpace(f)); f {
+		case formatAVIF, formatWebP:
+			formats = append(formats, f)
+		}
+	}
+	return formats
+}
+
+// acceptQualities parses an Accept header into the q-value of each media
+// type it lists. Types without a q parameter get 1.
+func acceptQualities(accept string) map[string]float64 {
+	qualities := make(map[string]float64)
+	for _, part := range strings.Split(accept, ",") {
+		params := strings.Split(part, ";")
+		mediaType := strings.ToLower(strings.TrimSpace(params[0]))
+		if mediaType == "" {
+			continue
+		}
+		q := 1.0
+		for _, param := range params[1:] {
+			name, value, _ := strings.Cut(strings.TrimSpace(param), "=")
+			if strings.EqualFold(strings.TrimSpace(name), "q") {
+				if v, err := strconv.ParseFloat(strings.TrimSpace(value), 64); err == nil && v >= 0 && v <= 1 {
+					q = v
+				} else {
+					q = 0
+				}
+			}
+		}
+		qualities[mediaType] = q
+	}
+	return qualities
+}
+
+// negotiateFormat picks the enabled format the Accept header rates highest,
+// preferring earlier formats on a tie and falling back to JPEG. Only formats
+// named explicitly count, since image/* is no promise of AVIF or WebP
+// support, and q=0 rules a format out.
+func negotiateFormat(accept string) string {
+	qualities := acceptQualities(accept)
+	best, bestQ := formatJPEG, 0.0
+	for _, f := range imageFormats {
+		if q := qualities["image/"+f]; q > bestQ {
+			best, bestQ = f, q
+		}
+	}
+	return best
+}
+
+// preferredImageFormat is the format served to current browsers, used when
+// warming the cache ahead of requests
+func preferredImageFormat() string {
+	if len(imageFormats) > 0 {
+		return imageFormats[0]
+	}
+	return formatJPEG
+}
+
+func formatExtension(format string) string {
+	if format == formatJPEG || format == "" {
+		return "jpg"
+	}
+	return format
+}
+
+// TMDB image size buckets per image type, as widths in pixels. Anything
+// larger is served from "original".
+var imageBuckets = map[string][]int{
+	"poster":   {92, 154, 185, 342, 500, 780},
+	"backdrop": {300, 780, 1280},
+	"profile":  {45, 185},
+	"still":    {92, 185, 300},
+}
+
+// imageBucket maps a requested width to the TMDB size to download and the
+// width to resize to locally (zero when the bucket already fits exactly)
+func imageBucket(imgType string, width int) (string, int) {
+	if width <= 0 {
+		return "original", 0
+	}
+	for _, w := range imageBuckets[imgType] {
+		if w == width {
+			return "w" + strconv.Itoa(w), 0
+		}
+		if w > width {
+			return "w" + strconv.Itoa(w), width
+		}
+	}
+	return "original", width
+}
+
+// transcodeImage re-encodes src as format, scaled down to width when it is
+// non-zero. Images are never scaled up.
+func transcodeImage(dst io.Writer, src io.Reader, width int, format string) error {
+	img, _, err := image.Decode(src)
+	if err != nil {
+		return err
+	}
+
+	bounds := img.Bounds()
+	if width > 0 && width < bounds.Dx() {
+		height := bounds.Dy() * width / bounds.Dx()
+		scaled := image.NewRGBA(image.Rect(0, 0, width, height))
+		draw.CatmullRom.Scale(scaled, scaled.Bounds(), img, bounds, draw.Over, nil)
+		img = scaled
+	}
+
+	switch format {
+	case formatWebP:
+		return webp.Encode(dst, img, webp.Options{Quality: 80, Method: 4})
+	case formatAVIF:
+		return avif.Encode(dst, img, avif.Options{Quality: 60, Speed: 8})
+	default:
+		return jpeg.Encode(dst, img, &jpeg.Options{Quality: 85})
+	}
+}
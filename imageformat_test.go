@@ -0,0 +1,41 @@
+package main
+
+import "testing"
+
+func TestNegotiateFormat(t *testing.T) {
+	tests := []struct {
+		accept string
+		want   string
+	}{
+		{"", formatJPEG},
+		{"*/*", formatJPEG},
+		{"image/*", formatJPEG},
+		{"image/avif,image/webp,image/apng,image/*,*/*;q=0.8", formatAVIF},
+		{"image/webp,*/*", formatWebP},
+		{"image/avif;q=0,image/webp", formatWebP},
+		{"image/avif; q=0.0, image/webp;q=0", formatJPEG},
+		{"image/avif;q=0.5,image/webp;q=0.9", formatWebP},
+		{"image/webp;q=0.8,image/avif;q=0.8", formatAVIF},
+		{"IMAGE/AVIF", formatAVIF},
+		{"image/avif;q=abc,image/webp", formatWebP},
+		{"image/avifx,image/webpx", formatJPEG},
+	}
+	for _, tt := range tests {
+		if got := negotiateFormat(tt.accept); got != tt.want {
+			t.Errorf("negotiateFormat(%q) = %q, want %q", tt.accept, got, tt.want)
+		}
+	}
+}
+
+func TestNegotiateFormatHonoursEnabledFormats(t *testing.T) {
+	defer func(formats []string) { imageFormats = formats }(imageFormats)
+
+	imageFormats = []string{formatWebP}
+	if got := negotiateFormat("image/avif,image/webp"); got != formatWebP {
+		t.Errorf("with only WebP enabled got %q", got)
+	}
+	imageFormats = nil
+	if got := negotiateFormat("image/avif,image/webp"); got != formatJPEG {
+		t.Errorf("with no formats enabled got %q", got)
+	}
+}
@@ -0,0 +1,471 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"cineseer/tmdb"
+
+	"github.com/gofiber/fiber/v2"
+	"golang.org/x/sync/singleflight"
+)
+
+var imageCacheDir = filepath.Join("static", "cache")
+
+// imageKey identifies a cached image. TMDB IDs are only unique within a
+// media type, so the type is part of every key.
+type imageKey struct {
+	MediaType string // movie, tv, person, collection, season or episode
+	ID        string // TMDB ID; "series-season[-episode]" for seasons and episodes
+	Type      string // poster, backdrop, profile or still
+	Width     int    // requested width in pixels; zero for the original size
+	Format    string // jpeg, webp or avif; empty means jpeg
+}
+
+func (k imageKey) filename() string {
+	name := fmt.Sprintf("%s-%s-%s", k.MediaType, k.ID, k.Type)
+	if k.Width > 0 {
+		name += fmt.Sprintf("-w%d", k.Width)
+	}
+	return name + "." + formatExtension(k.Format)
+}
+
+// mediaImageKey builds the key for a movie or series image
+func mediaImageKey(content tmdb.MediaContent, imgType string) imageKey {
+	mediaType := tmdb.MediaTypeTV
+	if isMovie(content) {
+		mediaType = tmdb.MediaTypeMovie
+	}
+	return imageKey{MediaType: mediaType, ID: fmt.Sprint(content.ID), Type: imgType}
+}
+
+// imageFlights coalesces concurrent downloads of the same cached image, such
+// as a page load racing the background warmer
+var imageFlights singleflight.Group
+
+// imageWaiters counts the callers of cacheImage waiting on a download
+var imageWaiters atomic.Int32
+
+// imageDownloadTimeout bounds a shared download, which no caller can cancel
+var imageDownloadTimeout = 2 * time.Minute
+
+// cacheImage downloads the TMDB image at imagePath into the image cache as
+// the variant described by key. Concurrent calls for one key share a single
+// download and its result. As with the TMDB client's coalescing, the
+// download is detached from ctx so one caller giving up doesn't fail the
+// others; ctx only limits how long this caller waits. Unlike there, the
+// download finishes even once every caller has gone, so the next request for
+// the image finds it cached.
+func cacheImage(ctx context.Context, client *tmdb.Client, imagePath string, key imageKey) error {
+	if imagePath == "" {
+		return fmt.Errorf("image path is empty")
+	}
+
+	ch := imageFlights.DoChan(key.filename(), func() (interface{}, error) {
+		// A download that finished just before this call started already
+		// did the work
+		if imageStore.Fresh(key.filename()) {
+			return nil, nil
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), imageDownloadTimeout)
+		defer cancel()
+		return nil, downloadImage(ctx, client, imagePath, key)
+	})
+	imageWaiters.Add(1)
+	defer imageWaiters.Add(-1)
+	select {
+	case res := <-ch:
+		return res.Err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Background encodes run one per spare CPU at most, each bounded by
+// backgroundEncodeTimeout, and at most once at a time per variant
+var (
+	encodeSlots             = make(chan struct{}, max(1, runtime.NumCPU()/2))
+	pendingEncodes          sync.Map // filename -> struct{}
+	backgroundEncodeTimeout = 2 * time.Minute
+)
+
+// encodeInBackground caches the variant described by key without making a
+// request wait for it, looking up its TMDB path with lookup
+func encodeInBackground(client *tmdb.Client, key imageKey, lookup func(ctx context.Context) (string, error)) {
+	name := key.filename()
+	if _, pending := pendingEncodes.LoadOrStore(name, struct{}{}); pending {
+		return
+	}
+	go func() {
+		defer pendingEncodes.Delete(name)
+		encodeSlots <- struct{}{}
+		defer func() { <-encodeSlots }()
+
+		ctx, cancel := context.WithTimeout(context.Background(), backgroundEncodeTimeout)
+		defer cancel()
+		imagePath, err := lookup(ctx)
+		if err == nil && imagePath != "" {
+			err = cacheImage(ctx, client, imagePath, key)
+		}
+		if err != nil {
+			log.Printf("Error encoding image %s in the background: %v", name, err)
+		}
+	}()
+}
+
+func downloadImage(ctx context.Context, client *tmdb.Client, imagePath string, key imageKey) error {
+	size, resize := imageBucket(key.Type, key.Width)
+	resp, err := client.OpenImage(ctx, size, imagePath)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	// Refuse error pages and anything else that isn't an image, checking
+	// both what TMDB claims and what the bytes look like
+	if ct := resp.Header.Get(fiber.HeaderContentType); ct != "" && !strings.HasPrefix(ct, "image/") {
+		return fmt.Errorf("unexpected content type %q", ct)
+	}
+	body := bufio.NewReader(resp.Body)
+	head, _ := body.Peek(512)
+	if sniffed := http.DetectContentType(head); !strings.HasPrefix(sniffed, "image/") {
+		return fmt.Errorf("downloaded %s, not an image", sniffed)
+	}
+
+	return imageStore.Store(key.filename(), func(w io.Writer) error {
+		// TMDB serves JPEGs, so an exact bucket needs no work
+		if resize == 0 && (key.Format == "" || key.Format == formatJPEG) {
+			_, err := io.Copy(w, body)
+			return err
+		}
+		return transcodeImage(w, body, resize, key.Format)
+	})
+}
+
+// maxImageWidth caps ?w= so arbitrary values can't fill the cache with
+// oversized variants
+const maxImageWidth = 2000
+
+// imageVariant applies the width requested with ?w=N or ?size=wN and the
+// format negotiated from the Accept header to key
+func imageVariant(c *fiber.Ctx, key imageKey) (imageKey, error) {
+	width := c.Query("w")
+	if size := c.Query("size"); width == "" && size != "" && size != "original" {
+		width = strings.TrimPrefix(size, "w")
+	}
+	if width != "" {
+		w, err := strconv.Atoi(width)
+		if err != nil || w <= 0 || w > maxImageWidth {
+			return key, fmt.Errorf("invalid image width %q", width)
+		}
+		key.Width = w
+	}
+	key.Format = negotiateFormat(c.Get(fiber.HeaderAccept))
+	return key, nil
+}
+
+// serveImage sends the cached image for key, first downloading it from the
+// TMDB path returned by lookup when it isn't cached yet or has outlived the
+// cache TTL. A stale copy is still served if fetching it again fails.
+func serveImage(c *fiber.Ctx, client *tmdb.Client, key imageKey, lookup func(ctx context.Context) (string, error)) error {
+	requested, err := imageVariant(c, key)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	// Another request can evict the file between looking it up and sending
+	// it, so a file gone missing is fetched once more
+	for retried := false; ; retried = true {
+		key := requested
+		name := key.filename()
+
+		cachePath, fresh, cached := imageStore.Lookup(name)
+		maxAge := imageMaxAge
+		// AVIF takes long enough to encode that it would stall the page, so it
+		// is done in the background while the stale copy or the JPEG is served
+		if !fresh && key.Format == formatAVIF {
+			encodeInBackground(client, key, lookup)
+			if cached {
+				// Serve the stale copy as is until the new one is ready
+				fresh = true
+			} else {
+				key.Format = formatJPEG
+				name = key.filename()
+				cachePath, fresh, cached = imageStore.Lookup(name)
+				maxAge = fallbackImageMaxAge
+			}
+		}
+		if !fresh {
+			imagePath, err := lookup(c.Context())
+			switch {
+			case err != nil && !cached:
+				return c.Status(404).JSON(fiber.Map{
+					"error": "Content not found",
+				})
+			case err == nil && imagePath == "":
+				return c.Status(404).JSON(fiber.Map{
+					"error": "No image available",
+				})
+			case err == nil:
+				err = cacheImage(c.Context(), client, imagePath, key)
+				if err == nil {
+					cachePath, _, cached = imageStore.Lookup(name)
+				}
+			}
+			if err != nil {
+				if !cached {
+					log.Printf("Error caching image %s: %v", name, err)
+					return c.Status(500).JSON(fiber.Map{
+						"error": "Failed to cache image",
+					})
+				}
+				log.Printf("Error revalidating image %s, serving stale copy: %v", name, err)
+			}
+		}
+
+		err := sendImage(c, cachePath, maxAge)
+		if retried || !imageMissing(err) {
+			return err
+		}
+		// SendFile set a 404 status, which would otherwise stick to the retry
+		imageStore.Forget(name)
+		c.Status(fiber.StatusOK)
+	}
+}
+
+// imageMissing reports whether sendImage failed because the file was gone
+func imageMissing(err error) bool {
+	var fiberErr *fiber.Error
+	return errors.As(err, &fiberErr) && fiberErr.Code == fiber.StatusNotFound
+}
+
+// How long browsers may keep an image, in seconds. A JPEG standing in for
+// an AVIF still being encoded is only kept briefly.
+const (
+	imageMaxAge         = 86400
+	fallbackImageMaxAge = 300
+)
+
+func sendImage(c *fiber.Ctx, path string, maxAge int) error {
+	// The same URL serves different formats depending on Accept
+	c.Vary(fiber.HeaderAccept)
+	c.Set(fiber.HeaderCacheControl, fmt.Sprintf("public, max-age=%d", maxAge))
+	return c.SendFile(path)
+}
+
+// setupImageRoutes registers the image proxy under api. Every route names
+// the media type so movie and series IDs can't collide.
+func setupImageRoutes(api fiber.Router, client *tmdb.Client) {
+	validType := func(c *fiber.Ctx, allowed ...string) (string, bool) {
+		imgType := c.Params("type")
+		for _, a := range allowed {
+			// Return the constant: params are only valid until the handler
+			// returns, and lookups may outlive it in the background
+			if imgType == a {
+				return a, true
+			}
+		}
+		return imgType, false
+	}
+	badRequest := func(c *fiber.Ctx, msg string) error {
+		return c.Status(400).JSON(fiber.Map{
+			"error": msg,
+		})
+	}
+
+	api.Get("/image/movie/:id/:type", func(c *fiber.Ctx) error {
+		id, err := c.ParamsInt("id")
+		imgType, ok := validType(c, "poster", "backdrop")
+		if err != nil || !ok {
+			return badRequest(c, "Invalid movie image")
+		}
+		key := imageKey{MediaType: tmdb.MediaTypeMovie, ID: fmt.Sprint(id), Type: imgType}
+		return serveImage(c, client, key, func(ctx context.Context) (string, error) {
+			details, err := client.MovieDetails(ctx, id)
+			if err != nil {
+				return "", err
+			}
+			if imgType == "poster" {
+				return details.PosterPath, nil
+			}
+			return details.BackdropPath, nil
+		})
+	})
+
+	api.Get("/image/tv/:id/:type", func(c *fiber.Ctx) error {
+		id, err := c.ParamsInt("id")
+		imgType, ok := validType(c, "poster", "backdrop")
+		if err != nil || !ok {
+			return badRequest(c, "Invalid series image")
+		}
+		key := imageKey{MediaType: tmdb.MediaTypeTV, ID: fmt.Sprint(id), Type: imgType}
+		return serveImage(c, client, key, func(ctx context.Context) (string, error) {
+			details, err := client.SeriesDetails(ctx, id)
+			if err != nil {
+				return "", err
+			}
+			if imgType == "poster" {
+				return details.PosterPath, nil
+			}
+			return details.BackdropPath, nil
+		})
+	})
+
+	api.Get("/image/tv/:id/season/:season/:type", func(c *fiber.Ctx) error {
+		id, err := c.ParamsInt("id")
+		season, seasonErr := c.ParamsInt("season")
+		imgType, ok := validType(c, "poster")
+		if err != nil || seasonErr != nil || !ok {
+			return badRequest(c, "Invalid season image")
+		}
+		key := imageKey{MediaType: "season", ID: fmt.Sprintf("%d-%d", id, season), Type: imgType}
+		return serveImage(c, client, key, func(ctx context.Context) (string, error) {
+			details, err := client.SeasonDetails(ctx, id, season)
+			if err != nil {
+				return "", err
+			}
+			return details.PosterPath, nil
+		})
+	})
+
+	api.Get("/image/tv/:id/season/:season/episode/:episode/:type", func(c *fiber.Ctx) error {
+		id, err := c.ParamsInt("id")
+		season, seasonErr := c.ParamsInt("season")
+		episode, episodeErr := c.ParamsInt("episode")
+		imgType, ok := validType(c, "still")
+		if err != nil || seasonErr != nil || episodeErr != nil || !ok {
+			return badRequest(c, "Invalid episode image")
+		}
+		key := imageKey{MediaType: "episode", ID: fmt.Sprintf("%d-%d-%d", id, season, episode), Type: imgType}
+		return serveImage(c, client, key, func(ctx context.Context) (string, error) {
+			details, err := client.SeasonDetails(ctx, id, season)
+			if err != nil {
+				return "", err
+			}
+			for _, ep := range details.Episodes {
+				if ep.EpisodeNumber == episode {
+					return ep.StillPath, nil
+				}
+			}
+			return "", fmt.Errorf("episode %d not found", episode)
+		})
+	})
+
+	// The episode page's gallery, by position in TMDB's list of stills
+	api.Get("/image/tv/:id/season/:season/episode/:episode/stills/:index", func(c *fiber.Ctx) error {
+		id, err := c.ParamsInt("id")
+		season, seasonErr := c.ParamsInt("season")
+		episode, episodeErr := c.ParamsInt("episode")
+		index, indexErr := c.ParamsInt("index")
+		if err != nil || seasonErr != nil || episodeErr != nil || indexErr != nil || index < 0 {
+			return badRequest(c, "Invalid episode still")
+		}
+		key := imageKey{MediaType: "episode", ID: fmt.Sprintf("%d-%d-%d-%d", id, season, episode, index), Type: "still"}
+		return serveImage(c, client, key, func(ctx context.Context) (string, error) {
+			details, err := client.EpisodeDetails(ctx, id, season, episode)
+			if err != nil {
+				return "", err
+			}
+			if index >= len(details.Images.Stills) {
+				return "", fmt.Errorf("episode %d has no still %d", episode, index)
+			}
+			return details.Images.Stills[index].FilePath, nil
+		})
+	})
+
+	api.Get("/image/person/:id/:type", func(c *fiber.Ctx) error {
+		id, err := c.ParamsInt("id")
+		imgType, ok := validType(c, "profile")
+		if err != nil || !ok {
+			return badRequest(c, "Invalid person image")
+		}
+		key := imageKey{MediaType: tmdb.MediaTypePerson, ID: fmt.Sprint(id), Type: imgType}
+		return serveImage(c, client, key, func(ctx context.Context) (string, error) {
+			person, err := client.PersonDetails(ctx, id)
+			if err != nil {
+				return "", err
+			}
+			return person.ProfilePath, nil
+		})
+	})
+
+	api.Get("/image/collection/:id/:type", func(c *fiber.Ctx) error {
+		id, err := c.ParamsInt("id")
+		imgType, ok := validType(c, "poster", "backdrop")
+		if err != nil || !ok {
+			return badRequest(c, "Invalid collection image")
+		}
+		key := imageKey{MediaType: "collection", ID: fmt.Sprint(id), Type: imgType}
+		return serveImage(c, client, key, func(ctx context.Context) (string, error) {
+			collection, err := client.CollectionDetails(ctx, id)
+			if err != nil {
+				return "", err
+			}
+			if imgType == "poster" {
+				return collection.PosterPath, nil
+			}
+			return collection.BackdropPath, nil
+		})
+	})
+}
+
+var legacyImageName = regexp.MustCompile(`^(\d+)-(poster|backdrop|profile)\.jpg$`)
+
+// migrateImageCache renames cache files from the old "{id}-{type}.jpg"
+// scheme. Profiles only ever belonged to people and are renamed; posters and
+// backdrops can't be attributed to a movie or a series (the old handler
+// sometimes stored one under the other's ID) so they are removed and will be
+// fetched again on demand.
+func migrateImageCache(dir string) error {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+
+	renamed, removed := 0, 0
+	for _, entry := range entries {
+		m := legacyImageName.FindStringSubmatch(entry.Name())
+		if entry.IsDir() || m == nil {
+			continue
+		}
+		oldPath := filepath.Join(dir, entry.Name())
+
+		if m[2] == "profile" {
+			key := imageKey{MediaType: tmdb.MediaTypePerson, ID: m[1], Type: m[2]}
+			if err := os.Rename(oldPath, filepath.Join(dir, key.filename())); err != nil {
+				return err
+			}
+			renamed++
+			continue
+		}
+
+		if err := os.Remove(oldPath); err != nil {
+			return err
+		}
+		removed++
+	}
+
+	if renamed > 0 || removed > 0 {
+		log.Printf("Migrated image cache: renamed %d, removed %d ambiguous legacy files", renamed, removed)
+	}
+	return nil
+}
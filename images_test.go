@@ -0,0 +1,206 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"image"
+	"image/jpeg"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"cineseer/tmdb"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// newImageTestClient serves a small JPEG for every image path once release
+// is closed, counting downloads, and gives the test an empty image cache
+func newImageTestClient(t *testing.T, release <-chan struct{}, downloads *atomic.Int32) *tmdb.Client {
+	t.Helper()
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		downloads.Add(1)
+		<-release
+		w.Header().Set("Content-Type", "image/jpeg")
+		jpeg.Encode(w, image.NewRGBA(image.Rect(0, 0, 20, 30)), nil)
+	})
+
+	previous := imageStore
+	t.Cleanup(func() { imageStore = previous })
+	var err error
+	imageStore, err = newImageCache(t.TempDir(), 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return client
+}
+
+// waitFor polls until done reports true, failing the test after a while
+func waitFor(t *testing.T, what string, done func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !done() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestCacheImageCallerGivingUpDoesNotFailOthers(t *testing.T) {
+	release := make(chan struct{})
+	var downloads atomic.Int32
+	client := newImageTestClient(t, release, &downloads)
+	key := imageKey{MediaType: tmdb.MediaTypeMovie, ID: "1", Type: "poster"}
+
+	// Once the first caller's download is under way, the second joins it
+	ctx, cancel := context.WithCancel(context.Background())
+	first := make(chan error, 1)
+	go func() { first <- cacheImage(ctx, client, "/a.jpg", key) }()
+	waitFor(t, "the download to start", func() bool { return downloads.Load() == 1 })
+	second := make(chan error, 1)
+	go func() { second <- cacheImage(context.Background(), client, "/a.jpg", key) }()
+	waitFor(t, "both callers to wait", func() bool { return imageWaiters.Load() == 2 })
+
+	// Abort the caller that started the download
+	cancel()
+	if err := <-first; !errors.Is(err, context.Canceled) {
+		t.Errorf("aborted caller error = %v, want context.Canceled", err)
+	}
+	close(release)
+
+	if err := <-second; err != nil {
+		t.Fatalf("waiting caller error = %v", err)
+	}
+	if !imageStore.Fresh(key.filename()) {
+		t.Error("image not cached")
+	}
+	if n := downloads.Load(); n != 1 {
+		t.Errorf("downloads = %d, want 1", n)
+	}
+}
+
+func TestMigrateImageCache(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		// Legacy names
+		"287-profile.jpg":   "profile",
+		"550-poster.jpg":    "poster",
+		"1399-backdrop.jpg": "backdrop",
+		// Current names and anything else are left alone
+		"movie-550-poster.jpg":       "new poster",
+		"tv-1399-backdrop-w780.webp": "new backdrop",
+		"person-31-profile.avif":     "new profile",
+		".gitkeep":                   "",
+		"notes.txt":                  "notes",
+		"550-poster.jpg.bak":         "backup",
+		"550-logo.jpg":               "logo",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Mkdir(filepath.Join(dir, "12-poster.jpg"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]string{
+		"person-287-profile.jpg":     "profile",
+		"movie-550-poster.jpg":       "new poster",
+		"tv-1399-backdrop-w780.webp": "new backdrop",
+		"person-31-profile.avif":     "new profile",
+		".gitkeep":                   "",
+		"notes.txt":                  "notes",
+		"550-poster.jpg.bak":         "backup",
+		"550-logo.jpg":               "logo",
+		"12-poster.jpg/":             "",
+	}
+	for run := 1; run <= 2; run++ {
+		if err := migrateImageCache(dir); err != nil {
+			t.Fatalf("run %d: %v", run, err)
+		}
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got := make(map[string]string)
+		for _, entry := range entries {
+			if entry.IsDir() {
+				got[entry.Name()+"/"] = ""
+				continue
+			}
+			content, err := os.ReadFile(filepath.Join(dir, entry.Name()))
+			if err != nil {
+				t.Fatal(err)
+			}
+			got[entry.Name()] = string(content)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("run %d left %v, want %v", run, got, want)
+		}
+	}
+
+	if err := migrateImageCache(filepath.Join(dir, "missing")); err != nil {
+		t.Errorf("missing cache directory: %v", err)
+	}
+}
+
+func TestServeImageRefetchesMissingFile(t *testing.T) {
+	var downloads atomic.Int32
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, ".jpg") {
+			downloads.Add(1)
+			w.Header().Set("Content-Type", "image/jpeg")
+			jpeg.Encode(w, image.NewRGBA(image.Rect(0, 0, 20, 30)), nil)
+			return
+		}
+		io.WriteString(w, `{"id": 1, "title": "Alien", "poster_path": "/alien.jpg"}`)
+	})
+	previous := imageStore
+	t.Cleanup(func() { imageStore = previous })
+	var err error
+	imageStore, err = newImageCache(t.TempDir(), 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	app := fiber.New()
+	setupImageRoutes(app.Group("/api"), client)
+
+	// The index still lists the poster, but another request evicted its file
+	name := imageKey{MediaType: tmdb.MediaTypeMovie, ID: "1", Type: "poster"}.filename()
+	err = imageStore.Store(name, func(w io.Writer) error {
+		_, err := io.WriteString(w, "evicted")
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	path, _, _ := imageStore.Lookup(name)
+	if err := os.Remove(path); err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/api/image/movie/1/poster", nil), -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("status %d, want 200: %s", resp.StatusCode, body)
+	}
+	if _, err := jpeg.Decode(bytes.NewReader(body)); err != nil {
+		t.Errorf("body is not the refetched JPEG: %v", err)
+	}
+	if n := downloads.Load(); n != 1 {
+		t.Errorf("downloads = %d, want 1", n)
+	}
+}
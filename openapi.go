@@ -0,0 +1,65 @@
+package main
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/filesystem"
+	swaggerFiles "github.com/swaggo/files/v2"
+)
+
+// openAPISpec documents the JSON API and image proxy. It is maintained by
+// hand next to the routes and DTOs; openapi_test.go fails when they drift.
+//
+//go:embed openapi.json
+var openAPISpec []byte
+
+// setupOpenAPI serves the spec at api/openapi.json and Swagger UI at
+// api/docs/
+func setupOpenAPI(api fiber.Router, basePath string) {
+	// Point the spec's server at wherever the app is mounted
+	var spec map[string]interface{}
+	if err := json.Unmarshal(openAPISpec, &spec); err != nil {
+		panic(fmt.Sprintf("invalid embedded openapi.json: %v", err))
+	}
+	spec["servers"] = []map[string]string{{"url": basePath + "/"}}
+	served, err := json.Marshal(spec)
+	if err != nil {
+		panic(fmt.Sprintf("encoding openapi.json: %v", err))
+	}
+
+	api.Get("/openapi.json", func(c *fiber.Ctx) error {
+		c.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSONCharsetUTF8)
+		return c.Send(served)
+	})
+
+	// Swagger UI ships with the petstore example configured; load ours
+	initializer := fmt.Sprintf(`window.onload = function() {
+  window.ui = SwaggerUIBundle({
+    url: %q,
+    dom_id: '#swagger-ui',
+    deepLinking: true,
+    presets: [SwaggerUIBundle.presets.apis, SwaggerUIStandalonePreset],
+    plugins: [SwaggerUIBundle.plugins.DownloadUrl],
+    layout: "StandaloneLayout"
+  });
+};
+`, basePath+"/api/openapi.json")
+	api.Get("/docs/swagger-initializer.js", func(c *fiber.Ctx) error {
+		c.Set(fiber.HeaderContentType, "text/javascript; charset=utf-8")
+		return c.SendString(initializer)
+	})
+
+	api.Use("/docs", func(c *fiber.Ctx) error {
+		// The UI loads its assets relative to the page
+		if c.Path() == basePath+"/api/docs" {
+			return c.Redirect(basePath+"/api/docs/", fiber.StatusMovedPermanently)
+		}
+		return c.Next()
+	}, filesystem.New(filesystem.Config{
+		Root: http.FS(swaggerFiles.FS),
+	}))
+}
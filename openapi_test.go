@@ -0,0 +1,224 @@
+package main
+
+import (
+	"encoding/json"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"cineseer/tmdb"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Routes under these prefixes are part of the documented API. The rest of
+// /api serves HTML fragments for htmx.
+var documentedPrefixes = []string{"/api/v1/", "/api/image/", "/api/warmer/", "/api/openapi.json"}
+
+// Go types behind each component schema
+var specSchemas = map[string]interface{}{
+	"APIResponse":      APIResponse{},
+	"APIErrorResponse": APIErrorResponse{},
+	"APIErrorBody":     APIErrorBody{},
+	"Pagination":       Pagination{},
+	"MediaSummary":     MediaSummary{},
+	"PersonSummary":    PersonSummary{},
+	"HomeSection":      HomeSection{},
+	"MediaDetails":     MediaDetails{},
+	"CreditDTO":        CreditDTO{},
+	"TrailerDTO":       TrailerDTO{},
+	"CollectionRef":    CollectionRef{},
+	"SeasonDTO":        SeasonDTO{},
+	"EpisodeDTO":       EpisodeDTO{},
+	"SearchResult":     SearchResult{},
+	"ImageDTO":         ImageDTO{},
+	"ImageSizeDTO":     ImageSizeDTO{},
+	"WarmerStatus":     WarmerStatus{},
+	"WarmerRun":        WarmerRun{},
+}
+
+// Schemas for ad-hoc fiber.Map bodies, which have no Go type to compare with
+var untypedSchemas = map[string]bool{
+	"ImageError": true,
+}
+
+type specDoc struct {
+	Paths      map[string]map[string]json.RawMessage `json:"paths"`
+	Components struct {
+		Schemas map[string]specSchema `json:"schemas"`
+	} `json:"components"`
+}
+
+type specSchema struct {
+	Type       string                `json:"type"`
+	Ref        string                `json:"$ref"`
+	Items      *specSchema           `json:"items"`
+	Properties map[string]specSchema `json:"properties"`
+	Required   []string              `json:"required"`
+}
+
+func loadSpec(t *testing.T) specDoc {
+	t.Helper()
+	var doc specDoc
+	if err := json.Unmarshal(openAPISpec, &doc); err != nil {
+		t.Fatalf("openapi.json is not valid JSON: %v", err)
+	}
+	return doc
+}
+
+var routeParam = regexp.MustCompile(`:(\w+)`)
+
+func TestOpenAPIRoutesMatchSpec(t *testing.T) {
+	client, err := tmdb.NewClient("test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	app := fiber.New()
+	setupFrontend(app, client)
+
+	registered := map[string]bool{}
+	for _, route := range app.GetRoutes(true) {
+		if route.Method != fiber.MethodGet {
+			continue
+		}
+		for _, prefix := range documentedPrefixes {
+			if strings.HasPrefix(route.Path, prefix) {
+				registered[routeParam.ReplaceAllString(route.Path, "{$1}")] = true
+			}
+		}
+	}
+
+	doc := loadSpec(t)
+	for path := range registered {
+		if _, ok := doc.Paths[path]; !ok {
+			t.Errorf("route GET %s is not in openapi.json", path)
+		}
+	}
+	for path, ops := range doc.Paths {
+		if !registered[path] {
+			t.Errorf("openapi.json documents %s, which is not a registered route", path)
+		}
+		for method := range ops {
+			if method != "get" {
+				t.Errorf("openapi.json documents %s %s, but only GET routes exist", strings.ToUpper(method), path)
+			}
+		}
+	}
+}
+
+func TestOpenAPISchemasMatchDTOs(t *testing.T) {
+	doc := loadSpec(t)
+
+	for name := range doc.Components.Schemas {
+		if _, ok := specSchemas[name]; !ok && !untypedSchemas[name] {
+			t.Errorf("schema %s has no Go type in specSchemas", name)
+		}
+	}
+
+	for name, value := range specSchemas {
+		schema, ok := doc.Components.Schemas[name]
+		if !ok {
+			t.Errorf("%T is missing from openapi.json as %s", value, name)
+			continue
+		}
+
+		fields := jsonFields(reflect.TypeOf(value))
+		var required []string
+		for field, f := range fields {
+			prop, ok := schema.Properties[field]
+			if !ok {
+				t.Errorf("%s.%s is missing from the spec", name, field)
+				continue
+			}
+			if want, got := specType(f.typ), schemaType(prop); want != "" && want != got {
+				t.Errorf("%s.%s is %s in Go but %s in the spec", name, field, want, got)
+			}
+			if !f.omitempty {
+				required = append(required, field)
+			}
+		}
+		for field := range schema.Properties {
+			if _, ok := fields[field]; !ok {
+				t.Errorf("spec documents %s.%s, which %T doesn't have", name, field, value)
+			}
+		}
+
+		// Fields that are always present are required, optional ones aren't
+		sort.Strings(required)
+		specRequired := append([]string(nil), schema.Required...)
+		sort.Strings(specRequired)
+		if strings.Join(required, ",") != strings.Join(specRequired, ",") {
+			t.Errorf("%s requires %v in the spec, but %T always sends %v", name, specRequired, value, required)
+		}
+	}
+}
+
+type jsonField struct {
+	typ       reflect.Type
+	omitempty bool
+}
+
+// jsonFields lists the JSON properties of a struct, flattening embedded
+// structs the way encoding/json does
+func jsonFields(t reflect.Type) map[string]jsonField {
+	fields := map[string]jsonField{}
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		if tag == "-" || !f.IsExported() {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+		if f.Anonymous && name == "" {
+			for k, v := range jsonFields(f.Type) {
+				fields[k] = v
+			}
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		fields[name] = jsonField{typ: f.Type, omitempty: strings.Contains(opts, "omitempty")}
+	}
+	return fields
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// specType describes a Go type the way schemaType describes a schema, or
+// returns "" for types that accept anything
+func specType(t reflect.Type) string {
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	switch {
+	case t == timeType:
+		return "string"
+	case t.Kind() == reflect.Struct:
+		return "#/components/schemas/" + t.Name()
+	case t.Kind() == reflect.Slice:
+		return "array of " + specType(t.Elem())
+	case t.Kind() == reflect.String:
+		return "string"
+	case t.Kind() == reflect.Bool:
+		return "boolean"
+	case t.Kind() >= reflect.Int && t.Kind() <= reflect.Uint64:
+		return "integer"
+	case t.Kind() == reflect.Float32 || t.Kind() == reflect.Float64:
+		return "number"
+	}
+	return ""
+}
+
+func schemaType(s specSchema) string {
+	switch {
+	case s.Ref != "":
+		return s.Ref
+	case s.Type == "array" && s.Items != nil:
+		return "array of " + schemaType(*s.Items)
+	}
+	return s.Type
+}
@@ -0,0 +1,391 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"cineseer/components"
+	"cineseer/tmdb"
+)
+
+const (
+	// Most recently updated watchlist titles used as seeds, per kind
+	recommendSeeds = 8
+	// Candidates whose keywords are fetched for re-ranking
+	recommendKeywordCandidates = 30
+	recommendResults           = 20
+	// How long a user's recommendations are reused while their watchlist
+	// stays the same
+	recommendTTL = time.Hour
+	// How long recommendations made while TMDB calls were failing are reused
+	recommendRetryTTL = 5 * time.Minute
+
+	// Similar titles match on genres and keywords, so they count for less
+	// than recommendations, which come from what other people watched
+	similarWeight = 0.6
+)
+
+// seedWeight is how much a watchlist title says about what a user likes:
+// more once they have started or finished it
+func seedWeight(status string) float64 {
+	if status == components.WatchWant {
+		return 0.6
+	}
+	return 1
+}
+
+// recommendation is a title suggested because of one of the user's seeds
+type recommendation struct {
+	Item    tmdb.MediaContent
+	Because string // title of the seed that contributed most
+	Score   float64
+
+	blend       float64
+	bestSeed    float64
+	genreScore  float64
+	keywordHits float64
+}
+
+// seed is a watchlist title and what TMDB relates to it
+type seed struct {
+	Item     WatchlistItem
+	Weight   float64
+	Genres   []int
+	Keywords []int
+	Lists    [2][]tmdb.MediaContent // recommendations, then similar titles
+}
+
+// profile weighs each genre or keyword by how many seeds share it, scaled so
+// the most common one is 1
+type profile map[int]float64
+
+func newProfile(seeds []*seed, ids func(*seed) []int) profile {
+	p := make(profile)
+	max := 0.0
+	for _, s := range seeds {
+		for _, id := range ids(s) {
+			p[id] += s.Weight
+			if p[id] > max {
+				max = p[id]
+			}
+		}
+	}
+	for id := range p {
+		p[id] /= max
+	}
+	return p
+}
+
+// overlap is the mean profile weight of ids, so titles matching only the
+// user's most common genres or keywords score 1
+func (p profile) overlap(ids []int) float64 {
+	if len(ids) == 0 {
+		return 0
+	}
+	total := 0.0
+	for _, id := range ids {
+		total += p[id]
+	}
+	return total / float64(len(ids))
+}
+
+// detailsFor fetches a movie or series with its keywords
+func detailsFor(ctx context.Context, client *tmdb.Client, kind string, id int) (*tmdb.DetailedContent, error) {
+	if kind == "movie" {
+		return client.MovieDetails(ctx, id, tmdb.AppendKeywords)
+	}
+	return client.SeriesDetails(ctx, id, tmdb.AppendKeywords)
+}
+
+func genreIDs(genres []tmdb.Genre) []int {
+	ids := make([]int, len(genres))
+	for i, g := range genres {
+		ids[i] = g.ID
+	}
+	return ids
+}
+
+func keywordIDs(keywords tmdb.Keywords) []int {
+	ids := make([]int, len(keywords.Keywords))
+	for i, k := range keywords.Keywords {
+		ids[i] = k.ID
+	}
+	return ids
+}
+
+// loadSeed fetches a seed's genres and keywords and the titles TMDB
+// recommends for it and considers similar to it. Whatever loaded is kept
+// even when some of it failed.
+func loadSeed(ctx context.Context, client *tmdb.Client, s *seed) error {
+	var wg sync.WaitGroup
+	errs := make([]error, 3)
+	wg.Add(3)
+	go func() {
+		defer wg.Done()
+		details, err := detailsFor(ctx, client, s.Item.Kind, s.Item.MediaID)
+		if err != nil {
+			errs[0] = fmt.Errorf("details of %s %d: %w", s.Item.Kind, s.Item.MediaID, err)
+			return
+		}
+		s.Genres = genreIDs(details.Genres)
+		s.Keywords = keywordIDs(details.Keywords)
+	}()
+	go func() {
+		defer wg.Done()
+		var response *tmdb.Response
+		var err error
+		if s.Item.Kind == "movie" {
+			response, err = client.RecommendedMovies(ctx, s.Item.MediaID)
+		} else {
+			response, err = client.RecommendedSeries(ctx, s.Item.MediaID)
+		}
+		if err != nil {
+			errs[1] = fmt.Errorf("recommendations for %s %d: %w", s.Item.Kind, s.Item.MediaID, err)
+			return
+		}
+		s.Lists[0] = response.Results
+	}()
+	go func() {
+		defer wg.Done()
+		var response *tmdb.Response
+		var err error
+		if s.Item.Kind == "movie" {
+			response, err = client.SimilarMovies(ctx, s.Item.MediaID)
+		} else {
+			response, err = client.SimilarSeries(ctx, s.Item.MediaID)
+		}
+		if err != nil {
+			errs[2] = fmt.Errorf("similar titles for %s %d: %w", s.Item.Kind, s.Item.MediaID, err)
+			return
+		}
+		s.Lists[1] = response.Results
+	}()
+	wg.Wait()
+	return errors.Join(errs...)
+}
+
+// pickSeeds takes the most recently updated watchlist titles of kind as
+// seeds, and returns every title of kind on the watchlist to leave out of
+// the results
+func pickSeeds(items []WatchlistItem, kind string) ([]*seed, map[int]bool) {
+	exclude := make(map[int]bool)
+	var seeds []*seed
+	for _, item := range items {
+		if item.Kind != kind {
+			continue
+		}
+		exclude[item.MediaID] = true
+		if len(seeds) < recommendSeeds {
+			seeds = append(seeds, &seed{Item: item, Weight: seedWeight(item.Status)})
+		}
+	}
+	return seeds, exclude
+}
+
+// blendCandidates merges the seeds' recommended and similar titles, higher
+// ranks and stronger seeds counting for more, scores each by how well its
+// genres match the seeds' and returns them best first
+func blendCandidates(seeds []*seed, exclude map[int]bool) []*recommendation {
+	candidates := make(map[int]*recommendation)
+	for _, s := range seeds {
+		for list, results := range s.Lists {
+			weight := s.Weight
+			if list == 1 {
+				weight *= similarWeight
+			}
+			for rank, item := range results {
+				if exclude[item.ID] || item.PosterPath == "" {
+					continue
+				}
+				r, ok := candidates[item.ID]
+				if !ok {
+					r = &recommendation{Item: item}
+					candidates[item.ID] = r
+				}
+				contribution := weight / float64(rank+1)
+				r.blend += contribution
+				if contribution > r.bestSeed {
+					r.bestSeed = contribution
+					r.Because = s.Item.Title
+				}
+			}
+		}
+	}
+
+	genres := newProfile(seeds, func(s *seed) []int { return s.Genres })
+	ranked := make([]*recommendation, 0, len(candidates))
+	for _, r := range candidates {
+		r.genreScore = genres.overlap(r.Item.GenreIDs)
+		ranked = append(ranked, r)
+	}
+	rankRecommendations(ranked)
+	return ranked
+}
+
+// rankRecommendations scores candidates, their blend scaled so the
+// strongest counts 1 next to genre and keyword overlap of up to 0.5 each,
+// and sorts them best first
+func rankRecommendations(ranked []*recommendation) {
+	maxBlend := 0.0
+	for _, r := range ranked {
+		if r.blend > maxBlend {
+			maxBlend = r.blend
+		}
+	}
+	for _, r := range ranked {
+		r.Score = 0.5*r.genreScore + 0.5*r.keywordHits
+		if maxBlend > 0 {
+			r.Score += r.blend / maxBlend
+		}
+	}
+	sort.SliceStable(ranked, func(i, j int) bool {
+		if ranked[i].Score != ranked[j].Score {
+			return ranked[i].Score > ranked[j].Score
+		}
+		return ranked[i].Item.ID < ranked[j].Item.ID
+	})
+}
+
+// recommend suggests movies or series for a user from their watchlist.
+//
+// Each seed's recommended and similar titles are blended, then re-ranked by
+// how well each candidate's genres and keywords match those of all the
+// seeds. Anything on the watchlist is left out, watched titles included.
+// complete is false when some TMDB call failed and the results are only a
+// best effort.
+func recommend(ctx context.Context, client *tmdb.Client, items []WatchlistItem, kind string) (results []recommendation, complete bool) {
+	seeds, exclude := pickSeeds(items, kind)
+	if len(seeds) == 0 {
+		return nil, true
+	}
+
+	var failed atomic.Bool
+	var wg sync.WaitGroup
+	for _, s := range seeds {
+		wg.Add(1)
+		go func(s *seed) {
+			defer wg.Done()
+			if err := loadSeed(ctx, client, s); err != nil {
+				log.Printf("Error loading recommendation seed: %v", err)
+				failed.Store(true)
+			}
+		}(s)
+	}
+	wg.Wait()
+
+	ranked := blendCandidates(seeds, exclude)
+	if len(ranked) == 0 {
+		return nil, !failed.Load()
+	}
+
+	// List results carry genres but not keywords, so only the front runners
+	// are fetched in full
+	keywords := newProfile(seeds, func(s *seed) []int { return s.Keywords })
+	for _, r := range ranked[:min(len(ranked), recommendKeywordCandidates)] {
+		wg.Add(1)
+		go func(r *recommendation) {
+			defer wg.Done()
+			details, err := detailsFor(ctx, client, kind, r.Item.ID)
+			if err != nil {
+				log.Printf("Error getting keywords of %s %d: %v", kind, r.Item.ID, err)
+				failed.Store(true)
+				return
+			}
+			r.keywordHits = keywords.overlap(keywordIDs(details.Keywords))
+		}(r)
+	}
+	wg.Wait()
+	rankRecommendations(ranked)
+
+	results = make([]recommendation, 0, recommendResults)
+	for _, r := range ranked[:min(len(ranked), recommendResults)] {
+		results = append(results, *r)
+	}
+	return results, !failed.Load()
+}
+
+type recommendKey struct {
+	UserID int64
+	Kind   string
+}
+
+type recommendEntry struct {
+	Fingerprint string
+	Expires     time.Time
+	Results     []recommendation
+}
+
+// recommendCache keeps each user's recommendations until they expire or
+// the watchlist changes
+var recommendCache = struct {
+	sync.Mutex
+	entries map[recommendKey]recommendEntry
+}{entries: make(map[recommendKey]recommendEntry)}
+
+// watchlistFingerprint changes whenever a title of kind is added, removed or
+// changes status
+func watchlistFingerprint(items []WatchlistItem, kind string) string {
+	var b strings.Builder
+	for _, item := range items {
+		if item.Kind == kind {
+			fmt.Fprintf(&b, "%d:%s,", item.MediaID, item.Status)
+		}
+	}
+	return b.String()
+}
+
+// personalRecommendations returns a user's recommendations of kind
+// ("movie" or "series") as cards explaining why each was picked. It returns
+// nothing for users with no titles of that kind on their watchlist.
+func personalRecommendations(ctx context.Context, client *tmdb.Client, userID int64, kind string) ([]components.MediaCardProps, error) {
+	items, err := userStore.Watchlist(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	key := recommendKey{userID, kind}
+	fingerprint := watchlistFingerprint(items, kind)
+
+	recommendCache.Lock()
+	entry, ok := recommendCache.entries[key]
+	recommendCache.Unlock()
+	if !ok || entry.Fingerprint != fingerprint || time.Now().After(entry.Expires) {
+		results, complete := recommend(ctx, client, items, kind)
+		// Results missing what TMDB failed to send are only kept until it
+		// may have recovered
+		ttl := recommendTTL
+		if !complete {
+			ttl = recommendRetryTTL
+		}
+		entry = recommendEntry{
+			Fingerprint: fingerprint,
+			Expires:     time.Now().Add(ttl),
+			Results:     results,
+		}
+		recommendCache.Lock()
+		recommendCache.entries[key] = entry
+		recommendCache.Unlock()
+	}
+
+	var cards []components.MediaCardProps
+	for _, r := range entry.Results {
+		item := r.Item
+		// Recommendation lists don't always say what they list
+		if kind == "movie" {
+			item.MediaType = tmdb.MediaTypeMovie
+		} else {
+			item.MediaType = tmdb.MediaTypeTV
+		}
+		if card, ok := mediaCardProps(item); ok {
+			card.Reason = "Because you liked " + r.Because
+			cards = append(cards, card)
+		}
+	}
+	return cards, nil
+}
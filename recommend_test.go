@@ -0,0 +1,280 @@
+package main
+
+import (
+	"context"
+	"io"
+	"math"
+	"net/http"
+	"reflect"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"cineseer/components"
+	"cineseer/tmdb"
+)
+
+func genresOf(s *seed) []int { return s.Genres }
+
+func TestNewProfile(t *testing.T) {
+	tests := []struct {
+		name  string
+		seeds []*seed
+		want  profile
+	}{
+		{"no seeds", nil, profile{}},
+		{
+			"shared genre scales to one",
+			[]*seed{
+				{Weight: 1, Genres: []int{18, 80}},
+				{Weight: 1, Genres: []int{18}},
+			},
+			profile{18: 1, 80: 0.5},
+		},
+		{
+			"weighted by seed",
+			[]*seed{
+				{Weight: 0.6, Genres: []int{18}},
+				{Weight: 1, Genres: []int{35}},
+				{Weight: 0.6, Genres: []int{35}},
+			},
+			profile{18: 0.375, 35: 1},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := newProfile(tt.seeds, genresOf)
+			if len(got) != len(tt.want) {
+				t.Fatalf("newProfile = %v, want %v", got, tt.want)
+			}
+			for id, want := range tt.want {
+				if math.Abs(got[id]-want) > 1e-9 {
+					t.Errorf("profile[%d] = %v, want %v", id, got[id], want)
+				}
+			}
+		})
+	}
+}
+
+func TestOverlap(t *testing.T) {
+	p := profile{18: 1, 80: 0.5}
+	tests := []struct {
+		ids  []int
+		want float64
+	}{
+		{nil, 0},
+		{[]int{18}, 1},
+		{[]int{18, 80}, 0.75},
+		{[]int{18, 99}, 0.5},
+		{[]int{99}, 0},
+	}
+	for _, tt := range tests {
+		if got := p.overlap(tt.ids); math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("overlap(%v) = %v, want %v", tt.ids, got, tt.want)
+		}
+	}
+}
+
+func TestPickSeeds(t *testing.T) {
+	var items []WatchlistItem
+	for id := 1; id <= recommendSeeds+2; id++ {
+		items = append(items, WatchlistItem{Kind: "movie", MediaID: id, Status: components.WatchWatched})
+	}
+	items = append(items, WatchlistItem{Kind: "series", MediaID: 100, Status: components.WatchWant})
+
+	seeds, exclude := pickSeeds(items, "movie")
+	if len(seeds) != recommendSeeds {
+		t.Fatalf("picked %d seeds, want %d", len(seeds), recommendSeeds)
+	}
+	for i, s := range seeds {
+		if s.Item.MediaID != i+1 {
+			t.Errorf("seed %d is %d, want the watchlist order kept", i, s.Item.MediaID)
+		}
+	}
+	if len(exclude) != recommendSeeds+2 {
+		t.Errorf("excluding %d titles, want every movie on the watchlist", len(exclude))
+	}
+	if exclude[100] {
+		t.Error("series excluded from movie recommendations")
+	}
+
+	seeds, _ = pickSeeds(items, "series")
+	if len(seeds) != 1 || seeds[0].Weight != seedWeight(components.WatchWant) {
+		t.Errorf("series seeds = %+v, want one weighted as wanted", seeds)
+	}
+}
+
+func poster(id int, genres ...int) tmdb.MediaContent {
+	return tmdb.MediaContent{ID: id, PosterPath: "/p.jpg", GenreIDs: genres}
+}
+
+func rankedIDs(ranked []*recommendation) []int {
+	ids := make([]int, len(ranked))
+	for i, r := range ranked {
+		ids[i] = r.Item.ID
+	}
+	return ids
+}
+
+func TestBlendCandidates(t *testing.T) {
+	tests := []struct {
+		name    string
+		seeds   []*seed
+		exclude map[int]bool
+		want    []int
+		because map[int]string
+	}{
+		{
+			"higher ranks first",
+			[]*seed{{Item: WatchlistItem{Title: "A"}, Weight: 1, Lists: [2][]tmdb.MediaContent{{poster(1), poster(2), poster(3)}}}},
+			nil,
+			[]int{1, 2, 3},
+			map[int]string{1: "A"},
+		},
+		{
+			"watchlist and posterless titles left out",
+			[]*seed{{Item: WatchlistItem{Title: "A"}, Weight: 1, Lists: [2][]tmdb.MediaContent{{poster(1), {ID: 2}, poster(3)}}}},
+			map[int]bool{1: true},
+			[]int{3},
+			nil,
+		},
+		{
+			"recommendations outweigh similar titles",
+			[]*seed{{Item: WatchlistItem{Title: "A"}, Weight: 1, Lists: [2][]tmdb.MediaContent{{poster(2)}, {poster(1)}}}},
+			nil,
+			[]int{2, 1},
+			nil,
+		},
+		{
+			"titles shared by seeds add up",
+			[]*seed{
+				{Item: WatchlistItem{Title: "A"}, Weight: 1, Lists: [2][]tmdb.MediaContent{{poster(1), poster(2)}}},
+				{Item: WatchlistItem{Title: "B"}, Weight: 1, Lists: [2][]tmdb.MediaContent{{poster(2), poster(3)}}},
+			},
+			nil,
+			[]int{2, 1, 3},
+			map[int]string{1: "A", 2: "B", 3: "B"},
+		},
+		{
+			"stronger seed names the reason",
+			[]*seed{
+				{Item: WatchlistItem{Title: "Wanted"}, Weight: 0.6, Lists: [2][]tmdb.MediaContent{{poster(1)}}},
+				{Item: WatchlistItem{Title: "Watched"}, Weight: 1, Lists: [2][]tmdb.MediaContent{{poster(2), poster(1)}}},
+			},
+			nil,
+			[]int{1, 2},
+			map[int]string{1: "Wanted", 2: "Watched"},
+		},
+		{
+			"genre match breaks a blend tie",
+			[]*seed{
+				{Item: WatchlistItem{Title: "A"}, Weight: 1, Genres: []int{18}, Lists: [2][]tmdb.MediaContent{{poster(1, 35)}}},
+				{Item: WatchlistItem{Title: "B"}, Weight: 1, Genres: []int{18}, Lists: [2][]tmdb.MediaContent{{poster(2, 18)}}},
+			},
+			nil,
+			[]int{2, 1},
+			nil,
+		},
+		{
+			"equal scores ordered by id",
+			[]*seed{
+				{Item: WatchlistItem{Title: "A"}, Weight: 1, Lists: [2][]tmdb.MediaContent{{poster(9)}}},
+				{Item: WatchlistItem{Title: "B"}, Weight: 1, Lists: [2][]tmdb.MediaContent{{poster(4)}}},
+			},
+			nil,
+			[]int{4, 9},
+			nil,
+		},
+		{"nothing to blend", []*seed{{Weight: 1}}, nil, []int{}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ranked := blendCandidates(tt.seeds, tt.exclude)
+			if got := rankedIDs(ranked); !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("ranked %v, want %v", got, tt.want)
+			}
+			for _, r := range ranked {
+				if want, ok := tt.because[r.Item.ID]; ok && r.Because != want {
+					t.Errorf("%d because %q, want %q", r.Item.ID, r.Because, want)
+				}
+			}
+		})
+	}
+}
+
+func TestRankRecommendationsKeywords(t *testing.T) {
+	ranked := []*recommendation{
+		{Item: poster(1), blend: 1},
+		{Item: poster(2), blend: 0.8, keywordHits: 1},
+	}
+	rankRecommendations(ranked)
+	if got := rankedIDs(ranked); !reflect.DeepEqual(got, []int{2, 1}) {
+		t.Errorf("ranked %v, want keyword matches to lift 2 above 1", got)
+	}
+	if want := 0.8 + 0.5; math.Abs(ranked[0].Score-want) > 1e-9 {
+		t.Errorf("score = %v, want %v", ranked[0].Score, want)
+	}
+}
+
+func TestDegradedRecommendationsAreCachedBriefly(t *testing.T) {
+	store := useTestStore(t)
+	ctx := context.Background()
+	user, err := store.CreateUser(ctx, "viewer", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := store.SetWatchStatus(ctx, user.ID, WatchlistItem{Kind: "movie", MediaID: 1, Status: components.WatchWatched, Title: "Alien"}); err != nil {
+		t.Fatal(err)
+	}
+
+	var similarFails atomic.Bool
+	similarFails.Store(true)
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/similar") && similarFails.Load():
+			w.WriteHeader(http.StatusBadGateway)
+		case strings.HasSuffix(r.URL.Path, "/recommendations"), strings.HasSuffix(r.URL.Path, "/similar"):
+			io.WriteString(w, `{"results": [{"id": 2, "title": "Aliens", "poster_path": "/p.jpg"}]}`)
+		default:
+			io.WriteString(w, `{"id": 1, "title": "Alien", "genres": [{"id": 27}]}`)
+		}
+	})
+
+	key := recommendKey{user.ID, "movie"}
+	defer func() {
+		recommendCache.Lock()
+		delete(recommendCache.entries, key)
+		recommendCache.Unlock()
+	}()
+	expiresIn := func() time.Duration {
+		recommendCache.Lock()
+		defer recommendCache.Unlock()
+		return time.Until(recommendCache.entries[key].Expires)
+	}
+
+	cards, err := personalRecommendations(ctx, client, user.ID, "movie")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cards) != 1 {
+		t.Errorf("got %d cards while similar titles failed, want the 1 recommendation", len(cards))
+	}
+	if ttl := expiresIn(); ttl > recommendRetryTTL {
+		t.Errorf("degraded results cached for %s, want at most %s", ttl, recommendRetryTTL)
+	}
+
+	// Once they expire, complete results replace them for the full TTL
+	similarFails.Store(false)
+	recommendCache.Lock()
+	entry := recommendCache.entries[key]
+	entry.Expires = time.Now().Add(-time.Second)
+	recommendCache.entries[key] = entry
+	recommendCache.Unlock()
+	if _, err := personalRecommendations(ctx, client, user.ID, "movie"); err != nil {
+		t.Fatal(err)
+	}
+	if ttl := expiresIn(); ttl <= recommendRetryTTL {
+		t.Errorf("complete results cached for %s, want %s", ttl, recommendTTL)
+	}
+}
@@ -1,219 +1,233 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"log"
 	"os"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
 
+	"cineseer/tmdb"
+
 	"github.com/gofiber/fiber/v2"
 	"github.com/joho/godotenv"
+	"golang.org/x/sync/singleflight"
 )
 
 type HomePageData struct {
-	TrendingTV        []MediaContent `json:"trending_tv"`
-	TrendingMovies    []MediaContent `json:"trending_movies"`
-	PopularTV         []MediaContent `json:"popular_tv"`
-	PopularMovies     []MediaContent `json:"popular_movies"`
-	UpcomingMovies    []MediaContent `json:"upcoming_movies"`
-	RecommendedTV     []MediaContent `json:"recommended_tv"`
-	RecommendedMovies []MediaContent `json:"recommended_movies"`
+	TrendingTV        []tmdb.MediaContent `json:"trending_tv"`
+	TrendingMovies    []tmdb.MediaContent `json:"trending_movies"`
+	PopularTV         []tmdb.MediaContent `json:"popular_tv"`
+	PopularMovies     []tmdb.MediaContent `json:"popular_movies"`
+	UpcomingMovies    []tmdb.MediaContent `json:"upcoming_movies"`
+	RecommendedTV     []tmdb.MediaContent `json:"recommended_tv"`
+	RecommendedMovies []tmdb.MediaContent `json:"recommended_movies"`
+
+	// When each section's lineup last changed, and when each title entered
+	// its section (keyed by homeItemKey); feeds are dated from these
+	Updated   map[string]time.Time `json:"-"`
+	FirstSeen map[string]time.Time `json:"-"`
 }
 
 // Helper function to determine if a MediaContent is a movie
-func isMovie(content MediaContent) bool {
+func isMovie(content tmdb.MediaContent) bool {
 	// If it has a release_date, it's a movie
 	// If it has a first_air_date, it's a TV show
 	return content.ReleaseDate != ""
 }
 
+// homeSection is one row of the home page and how to fetch it
+type homeSection struct {
+	key   string // matches the HomePageData JSON field
+	name  string
+	fetch func(ctx context.Context, client *tmdb.Client) (*tmdb.Response, error)
+	field func(data *HomePageData) *[]tmdb.MediaContent
+}
+
+var homeSections = []homeSection{
+	{"trending_tv", "trending TV", func(ctx context.Context, client *tmdb.Client) (*tmdb.Response, error) {
+		return client.TrendingSeries(ctx)
+	}, func(d *HomePageData) *[]tmdb.MediaContent { return &d.TrendingTV }},
+	{"trending_movies", "trending movies", func(ctx context.Context, client *tmdb.Client) (*tmdb.Response, error) {
+		return client.TrendingMovies(ctx)
+	}, func(d *HomePageData) *[]tmdb.MediaContent { return &d.TrendingMovies }},
+	{"popular_tv", "popular TV", func(ctx context.Context, client *tmdb.Client) (*tmdb.Response, error) {
+		return client.PopularSeries(ctx)
+	}, func(d *HomePageData) *[]tmdb.MediaContent { return &d.PopularTV }},
+	{"popular_movies", "popular movies", func(ctx context.Context, client *tmdb.Client) (*tmdb.Response, error) {
+		return client.PopularMovies(ctx)
+	}, func(d *HomePageData) *[]tmdb.MediaContent { return &d.PopularMovies }},
+	{"upcoming_movies", "upcoming movies", func(ctx context.Context, client *tmdb.Client) (*tmdb.Response, error) {
+		return client.UpcomingMovies(ctx)
+	}, func(d *HomePageData) *[]tmdb.MediaContent { return &d.UpcomingMovies }},
+	// Recommendations are based on the most popular title; signed-in viewers
+	// with a watchlist get their own from recommend.go instead
+	{"recommended_tv", "TV recommendations", func(ctx context.Context, client *tmdb.Client) (*tmdb.Response, error) {
+		popular, err := client.PopularSeries(ctx)
+		if err != nil || len(popular.Results) == 0 {
+			return popular, err
+		}
+		return client.RecommendedSeries(ctx, popular.Results[0].ID)
+	}, func(d *HomePageData) *[]tmdb.MediaContent { return &d.RecommendedTV }},
+	{"recommended_movies", "movie recommendations", func(ctx context.Context, client *tmdb.Client) (*tmdb.Response, error) {
+		popular, err := client.PopularMovies(ctx)
+		if err != nil || len(popular.Results) == 0 {
+			return popular, err
+		}
+		return client.RecommendedMovies(ctx, popular.Results[0].ID)
+	}, func(d *HomePageData) *[]tmdb.MediaContent { return &d.RecommendedMovies }},
+}
+
 var (
-	homePageCache     *HomePageData
+	homePageCache     *HomePageData // published snapshots are never modified
 	homePageMutex     sync.RWMutex
 	lastCacheRefresh  time.Time
+	homePageStaleAt   time.Time
+	homePageFlight    singleflight.Group
 	cacheRefreshHours = 3
-)
 
-func refreshHomePageCache() error {
-	homePageMutex.Lock()
-	defer homePageMutex.Unlock()
+	// How soon to try again after a refresh where some sections failed
+	homePageRetryDelay = 5 * time.Minute
+	// Upper bound on one refresh, which runs detached from any request
+	homePageRefreshTimeout = time.Minute
+)
 
-	newCache := HomePageData{
-		TrendingTV:        make([]MediaContent, 0),
-		TrendingMovies:    make([]MediaContent, 0),
-		PopularTV:         make([]MediaContent, 0),
-		PopularMovies:     make([]MediaContent, 0),
-		UpcomingMovies:    make([]MediaContent, 0),
-		RecommendedTV:     make([]MediaContent, 0),
-		RecommendedMovies: make([]MediaContent, 0),
+// refreshHomePageCache fetches every home page section and publishes a new
+// snapshot. Sections that fail or come back empty keep their previous
+// contents, so one bad call doesn't blank the page; the errors are returned
+// together and the refresh is retried sooner. A first refresh where every
+// section fails publishes nothing.
+func refreshHomePageCache(ctx context.Context, client *tmdb.Client) error {
+	homePageMutex.RLock()
+	previous := homePageCache
+	homePageMutex.RUnlock()
+	newCache := HomePageData{}
+	if previous != nil {
+		newCache = *previous
 	}
 
 	var wg sync.WaitGroup
-	var errChan = make(chan error, 8) // One for each API call
-
-	// Trending TV
-	wg.Add(1)
-	go func() {
-		defer wg.Done()
-		resp, err := get_trending_series()
-		if err != nil {
-			log.Printf("Error fetching trending TV: %v", err)
-			errChan <- err
-			return
-		}
-		if resp != nil && len(resp.Results) > 0 {
-			log.Printf("Fetched %d trending TV shows", len(resp.Results))
-			newCache.TrendingTV = resp.Results
-		}
-	}()
-
-	// Trending Movies
-	wg.Add(1)
-	go func() {
-		defer wg.Done()
-		resp, err := get_trending_movies()
-		if err != nil {
-			log.Printf("Error fetching trending movies: %v", err)
-			errChan <- err
-			return
-		}
-		if resp != nil && len(resp.Results) > 0 {
-			log.Printf("Fetched %d trending movies", len(resp.Results))
-            newCache.TrendingMovies = resp.Results
-        }
-	}()
-
-	// Popular TV
-	wg.Add(1)
-	go func() {
-		defer wg.Done()
-		resp, err := get_popular_series()
-		if err != nil {
-			log.Printf("Error fetching popular TV: %v", err)
-			errChan <- err
-			return
-		}
-		if resp != nil && len(resp.Results) > 0 {
-			log.Printf("Fetched %d popular TV shows", len(resp.Results))
-            newCache.PopularTV = resp.Results
-        }
-	}()
-
-	// Popular Movies
-	wg.Add(1)
-	go func() {
-		defer wg.Done()
-		resp, err := get_popular_movies()
-		if err != nil {
-			log.Printf("Error fetching popular movies: %v", err)
-			errChan <- err
-			return
-		}
-		if resp != nil && len(resp.Results) > 0 {
-			log.Printf("Fetched %d popular movies", len(resp.Results))
-            newCache.PopularMovies = resp.Results
-        }
-	}()
-
-	// Upcoming Movies
-	wg.Add(1)
-	go func() {
-		defer wg.Done()
-		resp, err := get_upcoming_movies()
-		if err != nil {
-			log.Printf("Error fetching upcoming movies: %v", err)
-			errChan <- err
-			return
-		}
-		if resp != nil && len(resp.Results) > 0 {
-			log.Printf("Fetched %d upcoming movies", len(resp.Results))
-            newCache.UpcomingMovies = resp.Results
-        }
-	}()
-
-	// Get recommendations based on first popular TV show
-	wg.Add(1)
-	go func() {
-		defer wg.Done()
-		resp, err := get_popular_series()
-		if err != nil {
-			log.Printf("Error fetching popular TV for recommendations: %v", err)
-			errChan <- err
-			return
-		}
-		if resp != nil && len(resp.Results) > 0 {
-			recResp, err := get_recommended_series(resp.Results[0].ID)
+	errs := make([]error, len(homeSections))
+	for i, section := range homeSections {
+		wg.Add(1)
+		go func(i int, section homeSection) {
+			defer wg.Done()
+			resp, err := section.fetch(ctx, client)
 			if err != nil {
-				log.Printf("Error fetching TV recommendations: %v", err)
-				errChan <- err
+				log.Printf("Error fetching %s: %v", section.name, err)
+				errs[i] = fmt.Errorf("%s: %w", section.name, err)
 				return
 			}
-			if recResp != nil && len(recResp.Results) > 0 {
-				log.Printf("Fetched %d TV recommendations", len(recResp.Results))
-                newCache.RecommendedTV = recResp.Results
-            }
-		}
-	}()
-
-	// Get recommendations based on first popular movie
-	wg.Add(1)
-	go func() {
-		defer wg.Done()
-		resp, err := get_popular_movies()
-		if err != nil {
-			log.Printf("Error fetching popular movies for recommendations: %v", err)
-			errChan <- err
-			return
-		}
-		if resp != nil && len(resp.Results) > 0 {
-			recResp, err := get_recommended_movies(resp.Results[0].ID)
-			if err != nil {
-				log.Printf("Error fetching movie recommendations: %v", err)
-				errChan <- err
-				return
+			if resp != nil && len(resp.Results) > 0 {
+				log.Printf("Fetched %d %s", len(resp.Results), section.name)
+				*section.field(&newCache) = resp.Results
 			}
-			if recResp != nil && len(recResp.Results) > 0 {
-				log.Printf("Fetched %d movie recommendations", len(recResp.Results))
-                newCache.RecommendedMovies = recResp.Results
-            }
-		}
-	}()
-
-	// Wait for all goroutines to complete
+		}(i, section)
+	}
 	wg.Wait()
-	close(errChan)
 
-	// Check for any errors
-	for err := range errChan {
-		if err != nil {
-			log.Printf("Error in refreshHomePageCache: %v", err)
-			return err
+	err := errors.Join(errs...)
+	// Nothing worth publishing until a section has loaded once: visitors keep
+	// getting the error page, and the next one tries again
+	if previous == nil && homePageEmpty(&newCache) {
+		if err == nil {
+			err = errors.New("every home page section came back empty")
+		}
+		log.Printf("Error refreshing home page cache: %v", err)
+		return err
+	}
+	for _, section := range homeSections {
+		if *section.field(&newCache) == nil {
+			*section.field(&newCache) = make([]tmdb.MediaContent, 0)
 		}
 	}
+	stampHomePage(previous, &newCache, time.Now())
 
+	homePageMutex.Lock()
+	defer homePageMutex.Unlock()
 	homePageCache = &newCache
 	lastCacheRefresh = time.Now()
+	if err != nil {
+		homePageStaleAt = lastCacheRefresh.Add(homePageRetryDelay)
+		log.Printf("Partially refreshed home page cache: %v", err)
+		return err
+	}
+	homePageStaleAt = lastCacheRefresh.Add(time.Hour * time.Duration(cacheRefreshHours))
 	log.Printf("Successfully refreshed home page cache")
 	return nil
 }
 
-func getHomePageData() (*HomePageData, error) {
+// startHomePageRefresh refreshes the home page cache in the background
+// unless a refresh is already running
+func startHomePageRefresh(client *tmdb.Client) <-chan singleflight.Result {
+	return homePageFlight.DoChan("home", func() (interface{}, error) {
+		ctx, cancel := context.WithTimeout(context.Background(), homePageRefreshTimeout)
+		defer cancel()
+		return nil, refreshHomePageCache(ctx, client)
+	})
+}
+
+// getHomePageData returns the cached home page right away, starting a
+// background refresh once it is stale. Only the very first request, before
+// anything is cached, waits on TMDB.
+func getHomePageData(ctx context.Context, client *tmdb.Client) (*HomePageData, error) {
 	homePageMutex.RLock()
-	if homePageCache != nil && time.Since(lastCacheRefresh) < time.Hour*time.Duration(cacheRefreshHours) {
-		defer homePageMutex.RUnlock()
-		return homePageCache, nil
-	}
+	data, stale := homePageCache, time.Now().After(homePageStaleAt)
 	homePageMutex.RUnlock()
 
-	if err := refreshHomePageCache(); err != nil {
-		log.Printf("Error refreshing home page cache: %v", err)
-		return nil, err
+	if data != nil {
+		if stale {
+			startHomePageRefresh(client)
+		}
+		return data, nil
 	}
 
-	homePageMutex.RLock()
-	defer homePageMutex.RUnlock()
-	return homePageCache, nil
+	select {
+	case res := <-startHomePageRefresh(client):
+		homePageMutex.RLock()
+		data = homePageCache
+		homePageMutex.RUnlock()
+		// A partial first refresh still has something to show
+		if data == nil {
+			return nil, res.Err
+		}
+		return data, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func homePageEmpty(data *HomePageData) bool {
+	for _, section := range homeSections {
+		if len(*section.field(data)) > 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// sweepResponseCache removes expired TMDB responses from disk now and then
+// every interval
+func sweepResponseCache(ctx context.Context, cache *tmdb.FileCache, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		removed, err := cache.Sweep(time.Now())
+		if err != nil {
+			log.Printf("Error sweeping TMDB response cache: %v", err)
+		} else if removed > 0 {
+			log.Printf("Removed %d expired TMDB responses from disk", removed)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
 }
 
 func main() {
@@ -230,6 +244,23 @@ func main() {
 	}
 	log.Printf("TMDB API Key loaded (length: %d)", len(apiKey))
 
+	// Keep TMDB responses in memory, and on disk when a cache directory is set
+	var responseCache tmdb.Cache = tmdb.NewMemoryCache(tmdb.DefaultCacheEntries)
+	if cacheDir := os.Getenv("TMDB_CACHE_DIR"); cacheDir != "" {
+		fileCache, err := tmdb.NewFileCache(cacheDir)
+		if err != nil {
+			log.Fatalf("Error creating TMDB response cache in %s: %v", cacheDir, err)
+		}
+		responseCache = tmdb.NewTieredCache(responseCache, fileCache)
+		go sweepResponseCache(context.Background(), fileCache, time.Hour)
+		log.Printf("Persisting TMDB responses to %s", cacheDir)
+	}
+
+	client, err := tmdb.NewClient(apiKey, tmdb.WithCache(responseCache))
+	if err != nil {
+		log.Fatalf("Error creating TMDB client: %v", err)
+	}
+
 	// Create fiber app
 	app := fiber.New()
 
@@ -243,9 +274,89 @@ func main() {
 		basePath = "/" + basePath
 	}
 	basePath = strings.TrimSuffix(basePath, "/")
-	
+
+	// Rename or drop image cache files from before media types were part
+	// of the cache key
+	if err := migrateImageCache(imageCacheDir); err != nil {
+		log.Printf("Warning: Error migrating image cache: %v", err)
+	}
+
+	// Bound the image cache on disk and refetch images after a while
+	maxBytes := int64(defaultImageCacheMaxBytes)
+	if mb := os.Getenv("IMAGE_CACHE_MAX_MB"); mb != "" {
+		n, err := strconv.ParseInt(mb, 10, 64)
+		if err != nil {
+			log.Fatalf("Invalid IMAGE_CACHE_MAX_MB %q: %v", mb, err)
+		}
+		maxBytes = n << 20
+	}
+	imageTTL := defaultImageCacheTTL
+	if ttl := os.Getenv("IMAGE_CACHE_TTL"); ttl != "" {
+		imageTTL, err = time.ParseDuration(ttl)
+		if err != nil {
+			log.Fatalf("Invalid IMAGE_CACHE_TTL %q: %v", ttl, err)
+		}
+	}
+	imageStore, err = newImageCache(imageCacheDir, maxBytes, imageTTL)
+	if err != nil {
+		log.Fatalf("Error opening image cache in %s: %v", imageCacheDir, err)
+	}
+
+	// Modern image formats offered to browsers that accept them, best first
+	if formats, ok := os.LookupEnv("IMAGE_FORMATS"); ok {
+		imageFormats = parseImageFormats(formats)
+		log.Printf("Serving image formats: %v", append(imageFormats, formatJPEG))
+	}
+
+	// Keep the home page and what it links to cached, independent of visitors
+	warmInterval := defaultWarmInterval
+	if interval := os.Getenv("WARM_INTERVAL"); interval != "" {
+		warmInterval, err = time.ParseDuration(interval)
+		if err != nil {
+			log.Fatalf("Invalid WARM_INTERVAL %q: %v", interval, err)
+		}
+	}
+	warmWorkers := defaultWarmWorkers
+	if workers := os.Getenv("WARM_WORKERS"); workers != "" {
+		warmWorkers, err = strconv.Atoi(workers)
+		if err == nil && warmWorkers < 1 {
+			err = errors.New("must be at least 1")
+		}
+		if err != nil {
+			log.Fatalf("Invalid WARM_WORKERS %q: %v", workers, err)
+		}
+	}
+	if warmInterval > 0 {
+		backgroundWarmer = newWarmer(client, warmInterval, warmWorkers)
+		backgroundWarmer.Start(context.Background())
+		log.Printf("Warming caches every %s with %d workers", warmInterval, warmWorkers)
+	} else {
+		log.Printf("Background warmer disabled")
+	}
+
+	// Accounts and sessions
+	auth, err = loadAuthConfig()
+	if err != nil {
+		log.Fatal(err)
+	}
+	databasePath := os.Getenv("DATABASE_PATH")
+	if databasePath == "" {
+		databasePath = defaultDatabasePath
+	}
+	// The rest of the site works without accounts, so a database that
+	// can't be opened only turns them off
+	if store, err := openSQLiteStore(databasePath); err != nil {
+		log.Printf("Warning: Error opening database %s, accounts are disabled: %v", databasePath, err)
+	} else {
+		userStore = store
+		go pruneSessions(context.Background(), userStore, time.Hour)
+		if auth.ProxyHeader != "" {
+			log.Printf("Trusting %s from %d proxy networks for sign in", auth.ProxyHeader, len(auth.TrustedProxies))
+		}
+	}
+
 	// Setup frontend routes
-	setupFrontend(app)
+	setupFrontend(app, client)
 
 	// Start the server
 	port := os.Getenv("PORT")
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"context"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"cineseer/tmdb"
+)
+
+// newTestClient points a TMDB client, API and images alike, at a fake TMDB
+// serving handler. Nothing is retried, rate limited, cached or logged unless
+// opts say otherwise.
+func newTestClient(t *testing.T, handler http.HandlerFunc, opts ...tmdb.Option) *tmdb.Client {
+	t.Helper()
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	opts = append([]tmdb.Option{
+		tmdb.WithBaseURL(server.URL),
+		tmdb.WithImageBaseURL(server.URL),
+		tmdb.WithRetryPolicy(tmdb.RetryPolicy{MaxAttempts: 1}),
+		tmdb.WithRateLimit(0, 0),
+		tmdb.WithCache(nil),
+		tmdb.WithLogger(log.New(io.Discard, "", 0)),
+	}, opts...)
+	client, err := tmdb.NewClient("test-key", opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return client
+}
+
+// resetHomePage clears the home page cache for a test and restores it after
+func resetHomePage(t *testing.T) {
+	t.Helper()
+	homePageMutex.Lock()
+	cache, refreshed, staleAt := homePageCache, lastCacheRefresh, homePageStaleAt
+	homePageCache, lastCacheRefresh, homePageStaleAt = nil, time.Time{}, time.Time{}
+	homePageMutex.Unlock()
+	t.Cleanup(func() {
+		homePageMutex.Lock()
+		homePageCache, lastCacheRefresh, homePageStaleAt = cache, refreshed, staleAt
+		homePageMutex.Unlock()
+	})
+}
+
+func TestFailedFirstHomePageRefreshPublishesNothing(t *testing.T) {
+	resetHomePage(t)
+	var healthy atomic.Bool
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if !healthy.Load() {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		if strings.HasPrefix(r.URL.Path, "/trending/movie") {
+			io.WriteString(w, `{"results": [{"id": 1, "title": "Alien", "release_date": "1979-05-25"}]}`)
+			return
+		}
+		io.WriteString(w, `{"results": []}`)
+	})
+
+	if err := refreshHomePageCache(context.Background(), client); err == nil {
+		t.Fatal("refresh with every section failing succeeded")
+	}
+	if data, err := getHomePageData(context.Background(), client); data != nil || err == nil {
+		t.Fatalf("getHomePageData after a failed first refresh = %v, %v; want an error", data, err)
+	}
+
+	healthy.Store(true)
+	data, err := getHomePageData(context.Background(), client)
+	if err != nil {
+		t.Fatalf("getHomePageData once TMDB recovered: %v", err)
+	}
+	if len(data.TrendingMovies) != 1 || data.PopularTV == nil {
+		t.Errorf("published snapshot = %+v, want one trending movie and empty rows", data)
+	}
+
+	// Later failures keep the last good rows
+	healthy.Store(false)
+	if err := refreshHomePageCache(context.Background(), client); err == nil {
+		t.Fatal("refresh with every section failing succeeded")
+	}
+	homePageMutex.RLock()
+	data = homePageCache
+	homePageMutex.RUnlock()
+	if data == nil || len(data.TrendingMovies) != 1 {
+		t.Errorf("snapshot after a failed refresh = %+v, want the last good one", data)
+	}
+}
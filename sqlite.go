@@ -0,0 +1,281 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	_ "modernc.org/sqlite"
+)
+
+// Default location of the SQLite database, overridable with DATABASE_PATH
+const defaultDatabasePath = "./data/cineseer.db"
+
+// sqliteMigrations are applied in order; PRAGMA user_version records how
+// many have run. Only ever append to this list.
+var sqliteMigrations = []string{
+	`CREATE TABLE users (
+		id            INTEGER PRIMARY KEY,
+		username      TEXT NOT NULL UNIQUE COLLATE NOCASE,
+		password_hash TEXT NOT NULL DEFAULT '',
+		created_at    INTEGER NOT NULL
+	);
+	CREATE TABLE sessions (
+		token_hash TEXT PRIMARY KEY,
+		user_id    INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+		expires_at INTEGER NOT NULL
+	);
+	CREATE INDEX sessions_expires_at ON sessions(expires_at);`,
+
+	`CREATE TABLE watchlist (
+		user_id    INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+		kind       TEXT NOT NULL,
+		media_id   INTEGER NOT NULL,
+		status     TEXT NOT NULL,
+		title      TEXT NOT NULL,
+		year       TEXT NOT NULL DEFAULT '',
+		overview   TEXT NOT NULL DEFAULT '',
+		added_at   INTEGER NOT NULL,
+		updated_at INTEGER NOT NULL,
+		PRIMARY KEY (user_id, kind, media_id)
+	);
+	CREATE TABLE watched_episodes (
+		user_id    INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+		series_id  INTEGER NOT NULL,
+		season     INTEGER NOT NULL,
+		episode    INTEGER NOT NULL,
+		watched_at INTEGER NOT NULL,
+		PRIMARY KEY (user_id, series_id, season, episode)
+	);`,
+}
+
+// sqliteStore is the SQLite implementation of UserStore
+type sqliteStore struct {
+	db *sql.DB
+}
+
+// openSQLiteStore opens (creating if needed) the database at path and
+// brings its schema up to date
+func openSQLiteStore(path string) (*sqliteStore, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, err
+	}
+	dsn := "file:" + path + "?_pragma=foreign_keys(1)&_pragma=journal_mode(WAL)&_pragma=busy_timeout(5000)"
+	db, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		return nil, err
+	}
+	store := &sqliteStore{db: db}
+	if err := store.migrate(context.Background()); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("migrating %s: %w", path, err)
+	}
+	return store, nil
+}
+
+func (s *sqliteStore) migrate(ctx context.Context) error {
+	var version int
+	if err := s.db.QueryRowContext(ctx, "PRAGMA user_version").Scan(&version); err != nil {
+		return err
+	}
+	for i := version; i < len(sqliteMigrations); i++ {
+		tx, err := s.db.BeginTx(ctx, nil)
+		if err != nil {
+			return err
+		}
+		if _, err := tx.ExecContext(ctx, sqliteMigrations[i]); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("migration %d: %w", i+1, err)
+		}
+		// PRAGMA doesn't take parameters
+		if _, err := tx.ExecContext(ctx, fmt.Sprintf("PRAGMA user_version = %d", i+1)); err != nil {
+			tx.Rollback()
+			return err
+		}
+		if err := tx.Commit(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *sqliteStore) Close() error {
+	return s.db.Close()
+}
+
+func (s *sqliteStore) CreateUser(ctx context.Context, username, passwordHash string) (*User, error) {
+	now := time.Now()
+	res, err := s.db.ExecContext(ctx,
+		"INSERT INTO users (username, password_hash, created_at) VALUES (?, ?, ?)",
+		username, passwordHash, now.Unix())
+	if err != nil {
+		if strings.Contains(err.Error(), "UNIQUE constraint failed") {
+			return nil, ErrUserExists
+		}
+		return nil, err
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return nil, err
+	}
+	return &User{ID: id, Username: username, PasswordHash: passwordHash, CreatedAt: time.Unix(now.Unix(), 0)}, nil
+}
+
+func (s *sqliteStore) scanUser(row *sql.Row) (*User, error) {
+	var user User
+	var created int64
+	if err := row.Scan(&user.ID, &user.Username, &user.PasswordHash, &created); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrUserNotFound
+		}
+		return nil, err
+	}
+	user.CreatedAt = time.Unix(created, 0)
+	return &user, nil
+}
+
+func (s *sqliteStore) UserByName(ctx context.Context, username string) (*User, error) {
+	return s.scanUser(s.db.QueryRowContext(ctx,
+		"SELECT id, username, password_hash, created_at FROM users WHERE username = ?", username))
+}
+
+func (s *sqliteStore) UserByID(ctx context.Context, id int64) (*User, error) {
+	return s.scanUser(s.db.QueryRowContext(ctx,
+		"SELECT id, username, password_hash, created_at FROM users WHERE id = ?", id))
+}
+
+func (s *sqliteStore) CountUsers(ctx context.Context) (int, error) {
+	var n int
+	err := s.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM users").Scan(&n)
+	return n, err
+}
+
+func (s *sqliteStore) CreateSession(ctx context.Context, tokenHash string, userID int64, expires time.Time) error {
+	_, err := s.db.ExecContext(ctx,
+		"INSERT INTO sessions (token_hash, user_id, expires_at) VALUES (?, ?, ?)",
+		tokenHash, userID, expires.Unix())
+	return err
+}
+
+func (s *sqliteStore) SessionUser(ctx context.Context, tokenHash string, now time.Time) (*User, error) {
+	return s.scanUser(s.db.QueryRowContext(ctx,
+		`SELECT u.id, u.username, u.password_hash, u.created_at
+		FROM sessions s JOIN users u ON u.id = s.user_id
+		WHERE s.token_hash = ? AND s.expires_at > ?`, tokenHash, now.Unix()))
+}
+
+func (s *sqliteStore) DeleteSession(ctx context.Context, tokenHash string) error {
+	_, err := s.db.ExecContext(ctx, "DELETE FROM sessions WHERE token_hash = ?", tokenHash)
+	return err
+}
+
+func (s *sqliteStore) DeleteExpiredSessions(ctx context.Context, now time.Time) error {
+	_, err := s.db.ExecContext(ctx, "DELETE FROM sessions WHERE expires_at <= ?", now.Unix())
+	return err
+}
+
+func (s *sqliteStore) SetWatchStatus(ctx context.Context, userID int64, item WatchlistItem) error {
+	now := time.Now().Unix()
+	_, err := s.db.ExecContext(ctx,
+		`INSERT INTO watchlist (user_id, kind, media_id, status, title, year, overview, added_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (user_id, kind, media_id) DO UPDATE SET
+			status = excluded.status, title = excluded.title, year = excluded.year,
+			overview = excluded.overview, updated_at = excluded.updated_at`,
+		userID, item.Kind, item.MediaID, item.Status, item.Title, item.Year, item.Overview, now, now)
+	return err
+}
+
+func (s *sqliteStore) RemoveFromWatchlist(ctx context.Context, userID int64, kind string, mediaID int) error {
+	_, err := s.db.ExecContext(ctx,
+		"DELETE FROM watchlist WHERE user_id = ? AND kind = ? AND media_id = ?", userID, kind, mediaID)
+	return err
+}
+
+const watchlistColumns = "kind, media_id, status, title, year, overview, added_at, updated_at"
+
+// scanWatchlistItem reads watchlistColumns from a row
+func scanWatchlistItem(row interface{ Scan(...interface{}) error }) (WatchlistItem, error) {
+	var item WatchlistItem
+	var added, updated int64
+	if err := row.Scan(&item.Kind, &item.MediaID, &item.Status, &item.Title, &item.Year, &item.Overview, &added, &updated); err != nil {
+		return item, err
+	}
+	item.AddedAt = time.Unix(added, 0)
+	item.UpdatedAt = time.Unix(updated, 0)
+	return item, nil
+}
+
+func (s *sqliteStore) Watchlist(ctx context.Context, userID int64) ([]WatchlistItem, error) {
+	rows, err := s.db.QueryContext(ctx,
+		`SELECT `+watchlistColumns+`
+		FROM watchlist WHERE user_id = ? ORDER BY updated_at DESC, added_at DESC`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var items []WatchlistItem
+	for rows.Next() {
+		item, err := scanWatchlistItem(rows)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+	return items, rows.Err()
+}
+
+func (s *sqliteStore) WatchlistItem(ctx context.Context, userID int64, kind string, mediaID int) (*WatchlistItem, error) {
+	item, err := scanWatchlistItem(s.db.QueryRowContext(ctx,
+		`SELECT `+watchlistColumns+`
+		FROM watchlist WHERE user_id = ? AND kind = ? AND media_id = ?`, userID, kind, mediaID))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotOnWatchlist
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &item, nil
+}
+
+func (s *sqliteStore) SetEpisodeWatched(ctx context.Context, userID int64, episode WatchedEpisode, watched bool) error {
+	if !watched {
+		_, err := s.db.ExecContext(ctx,
+			"DELETE FROM watched_episodes WHERE user_id = ? AND series_id = ? AND season = ? AND episode = ?",
+			userID, episode.SeriesID, episode.Season, episode.Episode)
+		return err
+	}
+	_, err := s.db.ExecContext(ctx,
+		`INSERT INTO watched_episodes (user_id, series_id, season, episode, watched_at)
+		VALUES (?, ?, ?, ?, ?) ON CONFLICT DO NOTHING`,
+		userID, episode.SeriesID, episode.Season, episode.Episode, episode.WatchedAt.Unix())
+	return err
+}
+
+func (s *sqliteStore) WatchedEpisodes(ctx context.Context, userID int64, seriesID int) ([]WatchedEpisode, error) {
+	rows, err := s.db.QueryContext(ctx,
+		`SELECT season, episode, watched_at FROM watched_episodes
+		WHERE user_id = ? AND series_id = ? ORDER BY season, episode`, userID, seriesID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var episodes []WatchedEpisode
+	for rows.Next() {
+		episode := WatchedEpisode{SeriesID: seriesID}
+		var watched int64
+		if err := rows.Scan(&episode.Season, &episode.Episode, &watched); err != nil {
+			return nil, err
+		}
+		episode.WatchedAt = time.Unix(watched, 0)
+		episodes = append(episodes, episode)
+	}
+	return episodes, rows.Err()
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// newTestStore opens a fresh database in a temporary directory
+func newTestStore(t *testing.T) *sqliteStore {
+	t.Helper()
+	store, err := openSQLiteStore(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { store.Close() })
+	return store
+}
+
+// useTestStore makes a fresh database the server's user store for a test
+func useTestStore(t *testing.T) *sqliteStore {
+	t.Helper()
+	store := newTestStore(t)
+	previous := userStore
+	userStore = store
+	t.Cleanup(func() { userStore = previous })
+	return store
+}
+
+func TestSQLiteMigrations(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.db")
+	for i := 0; i < 2; i++ {
+		store, err := openSQLiteStore(path)
+		if err != nil {
+			t.Fatalf("open %d: %v", i+1, err)
+		}
+		var version int
+		if err := store.db.QueryRow("PRAGMA user_version").Scan(&version); err != nil {
+			t.Fatal(err)
+		}
+		if version != len(sqliteMigrations) {
+			t.Errorf("open %d: user_version = %d, want %d", i+1, version, len(sqliteMigrations))
+		}
+		if i == 0 {
+			if _, err := store.CreateUser(context.Background(), "alice", ""); err != nil {
+				t.Fatal(err)
+			}
+		} else if n, err := store.CountUsers(context.Background()); err != nil || n != 1 {
+			t.Errorf("users after reopening = %d, %v; want the one kept", n, err)
+		}
+		store.Close()
+	}
+}
+
+func TestSQLiteUsers(t *testing.T) {
+	store := newTestStore(t)
+	ctx := context.Background()
+
+	alice, err := store.CreateUser(ctx, "Alice", "hash")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"Alice", "alice", "ALICE"} {
+		if _, err := store.CreateUser(ctx, name, ""); !errors.Is(err, ErrUserExists) {
+			t.Errorf("CreateUser(%q) again = %v, want ErrUserExists", name, err)
+		}
+	}
+
+	got, err := store.UserByName(ctx, "alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.ID != alice.ID || got.Username != "Alice" || got.PasswordHash != "hash" {
+		t.Errorf("UserByName = %+v, want %+v", got, alice)
+	}
+	if got, err := store.UserByID(ctx, alice.ID); err != nil || got.Username != "Alice" {
+		t.Errorf("UserByID = %+v, %v", got, err)
+	}
+	if _, err := store.UserByName(ctx, "bob"); !errors.Is(err, ErrUserNotFound) {
+		t.Errorf("UserByName(bob) = %v, want ErrUserNotFound", err)
+	}
+	if n, err := store.CountUsers(ctx); err != nil || n != 1 {
+		t.Errorf("CountUsers = %d, %v; want 1", n, err)
+	}
+}
+
+func TestSQLiteSessions(t *testing.T) {
+	store := newTestStore(t)
+	ctx := context.Background()
+	user, err := store.CreateUser(ctx, "alice", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+	if err := store.CreateSession(ctx, "live", user.ID, now.Add(time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.CreateSession(ctx, "expired", user.ID, now.Add(-time.Second)); err != nil {
+		t.Fatal(err)
+	}
+
+	if got, err := store.SessionUser(ctx, "live", now); err != nil || got.ID != user.ID {
+		t.Errorf("SessionUser(live) = %+v, %v", got, err)
+	}
+	for _, token := range []string{"expired", "unknown"} {
+		if _, err := store.SessionUser(ctx, token, now); !errors.Is(err, ErrUserNotFound) {
+			t.Errorf("SessionUser(%s) = %v, want ErrUserNotFound", token, err)
+		}
+	}
+	// Sessions end at their expiry, not a second later
+	if _, err := store.SessionUser(ctx, "live", now.Add(time.Hour)); !errors.Is(err, ErrUserNotFound) {
+		t.Errorf("SessionUser at expiry = %v, want ErrUserNotFound", err)
+	}
+
+	if err := store.DeleteExpiredSessions(ctx, now); err != nil {
+		t.Fatal(err)
+	}
+	var tokens []string
+	rows, err := store.db.Query("SELECT token_hash FROM sessions")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for rows.Next() {
+		var token string
+		if err := rows.Scan(&token); err != nil {
+			t.Fatal(err)
+		}
+		tokens = append(tokens, token)
+	}
+	rows.Close()
+	if len(tokens) != 1 || tokens[0] != "live" {
+		t.Errorf("sessions after pruning = %v, want [live]", tokens)
+	}
+
+	if err := store.DeleteSession(ctx, "live"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.SessionUser(ctx, "live", now); !errors.Is(err, ErrUserNotFound) {
+		t.Errorf("SessionUser after DeleteSession = %v, want ErrUserNotFound", err)
+	}
+}
@@ -0,0 +1,51 @@
+package tmdb
+
+import (
+	"net/url"
+	"strings"
+)
+
+// Append names a sub-resource fetched alongside movie or series details
+// through TMDB's append_to_response parameter.
+type Append string
+
+const (
+	AppendCredits        Append = "credits" // aggregate_credits for series
+	AppendKeywords       Append = "keywords"
+	AppendVideos         Append = "videos"
+	AppendReleaseDates   Append = "release_dates" // content_ratings for series
+	AppendExternalIDs    Append = "external_ids"
+	AppendWatchProviders Append = "watch/providers"
+)
+
+// DefaultAppends is every sub-resource DetailedContent knows how to decode.
+var DefaultAppends = []Append{
+	AppendCredits,
+	AppendKeywords,
+	AppendVideos,
+	AppendReleaseDates,
+	AppendExternalIDs,
+	AppendWatchProviders,
+}
+
+// appendParams builds the append_to_response query for a movie or series.
+func appendParams(appends []Append, series bool) url.Values {
+	if len(appends) == 0 {
+		return nil
+	}
+
+	names := make([]string, 0, len(appends))
+	for _, a := range appends {
+		name := string(a)
+		if series {
+			switch a {
+			case AppendCredits:
+				name = "aggregate_credits"
+			case AppendReleaseDates:
+				name = "content_ratings"
+			}
+		}
+		names = append(names, name)
+	}
+	return url.Values{"append_to_response": {strings.Join(names, ",")}}
+}
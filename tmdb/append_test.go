@@ -0,0 +1,122 @@
+package tmdb
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func TestAppendParams(t *testing.T) {
+	tests := []struct {
+		name    string
+		appends []Append
+		series  bool
+		want    string
+	}{
+		{"none", nil, false, ""},
+		{"movie", DefaultAppends, false, "credits,keywords,videos,release_dates,external_ids,watch/providers"},
+		{"series", DefaultAppends, true, "aggregate_credits,keywords,videos,content_ratings,external_ids,watch/providers"},
+		{"series without renamed appends", []Append{AppendVideos, AppendKeywords}, true, "videos,keywords"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := appendParams(tt.appends, tt.series).Get("append_to_response"); got != tt.want {
+				t.Errorf("append_to_response = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestKeywordsUnmarshalJSON(t *testing.T) {
+	tests := []struct {
+		name string
+		json string
+		want []string
+	}{
+		{"movie", `{"keywords": [{"id": 1, "name": "space"}, {"id": 2, "name": "alien"}]}`, []string{"space", "alien"}},
+		{"series", `{"results": [{"id": 3, "name": "rebellion"}]}`, []string{"rebellion"}},
+		{"empty", `{}`, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var k Keywords
+			if err := json.Unmarshal([]byte(tt.json), &k); err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, keyword := range k.Keywords {
+				got = append(got, keyword.Name)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("keywords = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAggregateCreditsFlatten(t *testing.T) {
+	var credits AggregateCredits
+	err := json.Unmarshal([]byte(`{
+		"cast": [
+			{"id": 1, "name": "Diego Luna", "roles": [
+				{"character": "Cassian Andor (young)", "episode_count": 3},
+				{"character": "Cassian Andor", "episode_count": 24}
+			]},
+			{"id": 2, "name": "Nobody", "roles": []}
+		],
+		"crew": [
+			{"id": 3, "name": "Tony Gilroy", "jobs": [
+				{"job": "Screenplay", "episode_count": 6},
+				{"job": "Executive Producer", "episode_count": 24}
+			]},
+			{"id": 4, "name": "Toby Haynes", "jobs": [{"job": "Director", "episode_count": 6}]}
+		]
+	}`), &credits)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := credits.Flatten()
+	want := Credits{
+		Cast: []CastMember{
+			{ID: 1, Name: "Diego Luna", Role: "Cassian Andor"},
+			{ID: 2, Name: "Nobody"},
+		},
+		Crew: []CrewMember{
+			{ID: 3, Name: "Tony Gilroy", Job: "Screenplay"},
+			{ID: 3, Name: "Tony Gilroy", Job: "Executive Producer"},
+			{ID: 4, Name: "Toby Haynes", Job: "Director"},
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Flatten() = %+v\nwant %+v", got, want)
+	}
+}
+
+func TestSeriesDetailsFlattensAggregateCredits(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if got := r.URL.Query().Get("append_to_response"); got != "aggregate_credits,keywords" {
+			t.Errorf("append_to_response = %q", got)
+		}
+		io.WriteString(w, `{
+			"id": 83867,
+			"name": "Andor",
+			"aggregate_credits": {"crew": [{"id": 3, "name": "Tony Gilroy", "jobs": [{"job": "Screenplay", "episode_count": 6}]}]},
+			"keywords": {"results": [{"id": 3, "name": "rebellion"}]}
+		}`)
+	})
+
+	details, err := c.SeriesDetails(context.Background(), 83867, AppendCredits, AppendKeywords)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []CrewMember{{ID: 3, Name: "Tony Gilroy", Job: "Screenplay"}}; !reflect.DeepEqual(details.Credits.Crew, want) {
+		t.Errorf("crew = %+v, want %+v", details.Credits.Crew, want)
+	}
+	if len(details.Keywords.Keywords) != 1 || details.Keywords.Keywords[0].Name != "rebellion" {
+		t.Errorf("keywords = %+v, want rebellion", details.Keywords.Keywords)
+	}
+}
@@ -0,0 +1,248 @@
+package tmdb
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Entry is a cached TMDB response body.
+type Entry struct {
+	Body    []byte    `json:"body"`
+	Expires time.Time `json:"expires"`
+}
+
+func (e Entry) expired(now time.Time) bool {
+	return !now.Before(e.Expires)
+}
+
+// Cache stores raw TMDB responses keyed by endpoint and query.
+// Implementations must be safe for concurrent use.
+type Cache interface {
+	Get(key string) (Entry, bool)
+	Set(key string, entry Entry)
+}
+
+// TTLFunc picks how long the response for endpoint may be cached.
+// A non-positive duration disables caching for that endpoint.
+type TTLFunc func(endpoint string) time.Duration
+
+var detailEndpoint = regexp.MustCompile(`^/(movie|tv|person|collection)/\d+(/season/\d+(/episode/\d+)?)?$`)
+
+// DefaultTTL caches details for a day, genre lists for a week and every
+// list-style endpoint (trending, popular, recommendations, ...) for an hour.
+func DefaultTTL(endpoint string) time.Duration {
+	switch {
+	case strings.HasPrefix(endpoint, "/genre/"):
+		return 7 * 24 * time.Hour
+	case detailEndpoint.MatchString(endpoint):
+		return 24 * time.Hour
+	default:
+		return time.Hour
+	}
+}
+
+// cacheKey identifies a request independent of the API key.
+func cacheKey(endpoint string, params url.Values) string {
+	if len(params) == 0 {
+		return endpoint
+	}
+	return endpoint + "?" + params.Encode()
+}
+
+// MemoryCache is an in-process Cache holding at most maxEntries responses.
+type MemoryCache struct {
+	mu         sync.Mutex
+	entries    map[string]Entry
+	maxEntries int
+}
+
+// NewMemoryCache creates a MemoryCache. A maxEntries of zero means unbounded.
+func NewMemoryCache(maxEntries int) *MemoryCache {
+	return &MemoryCache{
+		entries:    make(map[string]Entry),
+		maxEntries: maxEntries,
+	}
+}
+
+func (m *MemoryCache) Get(key string) (Entry, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	e, ok := m.entries[key]
+	if !ok {
+		return Entry{}, false
+	}
+	if e.expired(time.Now()) {
+		delete(m.entries, key)
+		return Entry{}, false
+	}
+	return e, true
+}
+
+func (m *MemoryCache) Set(key string, entry Entry) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.maxEntries > 0 && len(m.entries) >= m.maxEntries {
+		m.evictLocked()
+	}
+	m.entries[key] = entry
+}
+
+// evictLocked drops expired entries, then the entries closest to expiry
+// until there is room for one more.
+func (m *MemoryCache) evictLocked() {
+	now := time.Now()
+	for k, e := range m.entries {
+		if e.expired(now) {
+			delete(m.entries, k)
+		}
+	}
+	for len(m.entries) >= m.maxEntries {
+		var oldestKey string
+		var oldest time.Time
+		for k, e := range m.entries {
+			if oldestKey == "" || e.Expires.Before(oldest) {
+				oldestKey, oldest = k, e.Expires
+			}
+		}
+		delete(m.entries, oldestKey)
+	}
+}
+
+// FileCache persists responses as JSON files in a directory so they survive
+// restarts. Expired files are removed when read or by Sweep.
+type FileCache struct {
+	dir string
+}
+
+// NewFileCache creates dir if needed and returns a FileCache rooted there.
+func NewFileCache(dir string) (*FileCache, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	return &FileCache{dir: dir}, nil
+}
+
+func (f *FileCache) path(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return filepath.Join(f.dir, hex.EncodeToString(sum[:])+".json")
+}
+
+func (f *FileCache) Get(key string) (Entry, bool) {
+	data, err := os.ReadFile(f.path(key))
+	if err != nil {
+		return Entry{}, false
+	}
+	var e Entry
+	if err := json.Unmarshal(data, &e); err != nil {
+		return Entry{}, false
+	}
+	if e.expired(time.Now()) {
+		os.Remove(f.path(key))
+		return Entry{}, false
+	}
+	return e, true
+}
+
+func (f *FileCache) Set(key string, entry Entry) {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return
+	}
+	// Write to a temp file and rename so readers never see partial files.
+	tmp, err := os.CreateTemp(f.dir, fileCacheTempPrefix+"*")
+	if err != nil {
+		return
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return
+	}
+	if err := os.Rename(tmp.Name(), f.path(key)); err != nil {
+		os.Remove(tmp.Name())
+	}
+}
+
+// fileCacheTempPrefix marks responses still being written
+const fileCacheTempPrefix = "tmp-"
+
+// Sweep removes expired responses, and temp files left behind by writes
+// that never finished, returning how many files it removed. Without it,
+// responses that are never requested again stay on disk forever.
+func (f *FileCache) Sweep(now time.Time) (int, error) {
+	entries, err := os.ReadDir(f.dir)
+	if err != nil {
+		return 0, err
+	}
+	removed := 0
+	for _, de := range entries {
+		if de.IsDir() {
+			continue
+		}
+		name := de.Name()
+		path := filepath.Join(f.dir, name)
+		switch {
+		case strings.HasPrefix(name, fileCacheTempPrefix):
+			// Leave writes that may still be in progress alone
+			info, err := de.Info()
+			if err != nil || now.Sub(info.ModTime()) < time.Hour {
+				continue
+			}
+		case strings.HasSuffix(name, ".json"):
+			data, err := os.ReadFile(path)
+			if err != nil {
+				continue
+			}
+			var e Entry
+			if err := json.Unmarshal(data, &e); err == nil && !e.expired(now) {
+				continue
+			}
+		default:
+			continue
+		}
+		if err := os.Remove(path); err == nil {
+			removed++
+		}
+	}
+	return removed, nil
+}
+
+// TieredCache reads through a fast cache in front of a slower, persistent one.
+type TieredCache struct {
+	front Cache
+	back  Cache
+}
+
+func NewTieredCache(front, back Cache) *TieredCache {
+	return &TieredCache{front: front, back: back}
+}
+
+func (t *TieredCache) Get(key string) (Entry, bool) {
+	if e, ok := t.front.Get(key); ok {
+		return e, true
+	}
+	e, ok := t.back.Get(key)
+	if ok {
+		t.front.Set(key, e)
+	}
+	return e, ok
+}
+
+func (t *TieredCache) Set(key string, entry Entry) {
+	t.front.Set(key, entry)
+	t.back.Set(key, entry)
+}
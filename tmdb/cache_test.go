@@ -0,0 +1,168 @@
+package tmdb
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestDefaultTTL(t *testing.T) {
+	tests := []struct {
+		endpoint string
+		want     time.Duration
+	}{
+		{"/genre/movie/list", 7 * 24 * time.Hour},
+		{"/movie/550", 24 * time.Hour},
+		{"/tv/1399/season/1", 24 * time.Hour},
+		{"/tv/1399/season/1/episode/2", 24 * time.Hour},
+		{"/person/287", 24 * time.Hour},
+		{"/trending/movie/week", time.Hour},
+		{"/movie/550/recommendations", time.Hour},
+		{"/search/multi", time.Hour},
+	}
+	for _, tt := range tests {
+		if got := DefaultTTL(tt.endpoint); got != tt.want {
+			t.Errorf("DefaultTTL(%q) = %s, want %s", tt.endpoint, got, tt.want)
+		}
+	}
+}
+
+func TestMemoryCacheExpiry(t *testing.T) {
+	m := NewMemoryCache(0)
+	m.Set("fresh", Entry{Body: []byte("a"), Expires: time.Now().Add(time.Hour)})
+	m.Set("expired", Entry{Body: []byte("b"), Expires: time.Now().Add(-time.Second)})
+
+	if e, ok := m.Get("fresh"); !ok || string(e.Body) != "a" {
+		t.Errorf("Get(fresh) = %q, %v", e.Body, ok)
+	}
+	if _, ok := m.Get("expired"); ok {
+		t.Error("Get(expired) hit")
+	}
+	if _, ok := m.entries["expired"]; ok {
+		t.Error("expired entry still stored after Get")
+	}
+}
+
+func TestMemoryCacheEviction(t *testing.T) {
+	now := time.Now()
+	m := NewMemoryCache(3)
+	m.Set("soon", Entry{Expires: now.Add(time.Minute)})
+	m.Set("later", Entry{Expires: now.Add(time.Hour)})
+	m.Set("latest", Entry{Expires: now.Add(2 * time.Hour)})
+
+	// Full: the entry closest to expiry goes first
+	m.Set("new", Entry{Expires: now.Add(30 * time.Minute)})
+	if _, ok := m.Get("soon"); ok {
+		t.Error("entry closest to expiry survived eviction")
+	}
+	for _, key := range []string{"later", "latest", "new"} {
+		if _, ok := m.Get(key); !ok {
+			t.Errorf("%s was evicted", key)
+		}
+	}
+
+	// Expired entries go before any live one, however close to expiry
+	m.entries["later"] = Entry{Expires: now.Add(-time.Second)}
+	m.Set("newer", Entry{Expires: now.Add(3 * time.Hour)})
+	if _, ok := m.entries["later"]; ok {
+		t.Error("expired entry survived eviction")
+	}
+	if _, ok := m.Get("new"); !ok {
+		t.Error("live entry evicted while an expired one was stored")
+	}
+	if len(m.entries) != 3 {
+		t.Errorf("cache holds %d entries, want 3", len(m.entries))
+	}
+}
+
+func TestFileCache(t *testing.T) {
+	f, err := NewFileCache(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Set("/movie/1", Entry{Body: []byte(`{"id":1}`), Expires: time.Now().Add(time.Hour)})
+	f.Set("/movie/2", Entry{Body: []byte(`{"id":2}`), Expires: time.Now().Add(-time.Second)})
+
+	if e, ok := f.Get("/movie/1"); !ok || string(e.Body) != `{"id":1}` {
+		t.Errorf("Get(/movie/1) = %q, %v", e.Body, ok)
+	}
+	if _, ok := f.Get("/movie/2"); ok {
+		t.Error("Get of an expired entry hit")
+	}
+	if _, err := os.Stat(f.path("/movie/2")); !os.IsNotExist(err) {
+		t.Errorf("expired entry still on disk after Get: %v", err)
+	}
+}
+
+func TestFileCacheSweep(t *testing.T) {
+	dir := t.TempDir()
+	f, err := NewFileCache(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+	f.Set("fresh", Entry{Expires: now.Add(time.Hour)})
+	f.Set("expired", Entry{Expires: now.Add(-time.Hour)})
+
+	write := func(name string, age time.Duration) {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte("x"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(path, now.Add(-age), now.Add(-age)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write(fileCacheTempPrefix+"abandoned", 2*time.Hour)
+	write(fileCacheTempPrefix+"writing", 0)
+	write("corrupt.json", 0)
+	write("README", 0)
+
+	removed, err := f.Sweep(now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if removed != 3 {
+		t.Errorf("Sweep removed %d files, want 3", removed)
+	}
+	for _, path := range []string{f.path("fresh"), filepath.Join(dir, fileCacheTempPrefix+"writing"), filepath.Join(dir, "README")} {
+		if _, err := os.Stat(path); err != nil {
+			t.Errorf("%s removed by Sweep: %v", filepath.Base(path), err)
+		}
+	}
+	for _, path := range []string{f.path("expired"), filepath.Join(dir, fileCacheTempPrefix+"abandoned"), filepath.Join(dir, "corrupt.json")} {
+		if _, err := os.Stat(path); !os.IsNotExist(err) {
+			t.Errorf("%s kept by Sweep", filepath.Base(path))
+		}
+	}
+}
+
+func TestTieredCachePromotes(t *testing.T) {
+	front, back := NewMemoryCache(0), NewMemoryCache(0)
+	tiered := NewTieredCache(front, back)
+	entry := Entry{Body: []byte("x"), Expires: time.Now().Add(time.Hour)}
+
+	back.Set("key", entry)
+	if _, ok := front.Get("key"); ok {
+		t.Fatal("front hit before any read through the tiers")
+	}
+	if e, ok := tiered.Get("key"); !ok || string(e.Body) != "x" {
+		t.Fatalf("tiered Get = %q, %v", e.Body, ok)
+	}
+	if _, ok := front.Get("key"); !ok {
+		t.Error("back hit not promoted to front")
+	}
+
+	tiered.Set("other", entry)
+	if _, ok := front.Get("other"); !ok {
+		t.Error("Set skipped the front cache")
+	}
+	if _, ok := back.Get("other"); !ok {
+		t.Error("Set skipped the back cache")
+	}
+
+	if _, ok := tiered.Get("missing"); ok {
+		t.Error("Get(missing) hit")
+	}
+}
@@ -0,0 +1,369 @@
+// Package tmdb is a small client for The Movie Database (TMDB) v3 API.
+package tmdb
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	DefaultBaseURL      = "https://api.themoviedb.org/3"
+	DefaultImageBaseURL = "https://image.tmdb.org/t/p"
+	DefaultTimeout      = 15 * time.Second
+
+	// TMDB allows roughly 50 requests per second per IP; stay below that.
+	DefaultRateLimit = 40
+	DefaultRateBurst = 20
+
+	DefaultCacheEntries = 5000
+)
+
+// ErrMissingAPIKey is returned by NewClient when no API key is supplied.
+var ErrMissingAPIKey = errors.New("tmdb: API key is required")
+
+// APIError is returned when TMDB answers with a non-200 status code.
+type APIError struct {
+	StatusCode int
+	Body       string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("TMDB API error: %s (Status: %d)", e.Body, e.StatusCode)
+}
+
+// Client talks to the TMDB API. It is safe for concurrent use.
+//
+// Every method takes a context that bounds how long the caller waits.
+// Identical requests in flight at the same time share one upstream fetch. A
+// caller giving up doesn't fail the others; the fetch is cancelled only once
+// every caller waiting for it has given up.
+type Client struct {
+	baseURL      string
+	imageBaseURL string
+	apiKey       string
+	httpClient   *http.Client
+	timeout      time.Duration
+	retry        RetryPolicy
+	limiter      *rateLimiter
+	cache        Cache
+	ttl          TTLFunc
+	appends      []Append
+	logger       *log.Logger
+
+	flightsMu sync.Mutex
+	flights   map[string]*flight
+}
+
+// flight is an upstream fetch shared by the callers waiting for it
+type flight struct {
+	done    chan struct{} // closed once body and err are set
+	body    []byte
+	err     error
+	waiters int
+	cancel  context.CancelFunc
+}
+
+// Option configures a Client.
+type Option func(*Client)
+
+// WithBaseURL points the client at a different API root, e.g. a local fake server.
+func WithBaseURL(u string) Option {
+	return func(c *Client) { c.baseURL = strings.TrimSuffix(u, "/") }
+}
+
+// WithImageBaseURL points image downloads at a different host.
+func WithImageBaseURL(u string) Option {
+	return func(c *Client) { c.imageBaseURL = strings.TrimSuffix(u, "/") }
+}
+
+// WithHTTPClient replaces the underlying HTTP client.
+func WithHTTPClient(hc *http.Client) Option {
+	return func(c *Client) { c.httpClient = hc }
+}
+
+// WithTimeout sets the per-request timeout. Zero disables it.
+func WithTimeout(d time.Duration) Option {
+	return func(c *Client) { c.timeout = d }
+}
+
+// WithRetryPolicy replaces DefaultRetryPolicy. A policy with MaxAttempts of
+// one disables retries.
+func WithRetryPolicy(p RetryPolicy) Option {
+	return func(c *Client) { c.retry = p }
+}
+
+// WithRateLimit caps outgoing API requests to perSecond with the given burst.
+// A non-positive rate disables client-side limiting.
+func WithRateLimit(perSecond float64, burst int) Option {
+	return func(c *Client) { c.limiter = newRateLimiter(perSecond, burst) }
+}
+
+// WithCache stores successful responses in cache. Pass nil to disable
+// response caching.
+func WithCache(cache Cache) Option {
+	return func(c *Client) { c.cache = cache }
+}
+
+// WithCacheTTL replaces DefaultTTL.
+func WithCacheTTL(ttl TTLFunc) Option {
+	return func(c *Client) { c.ttl = ttl }
+}
+
+// WithDetailAppends sets the sub-resources fetched with movie and series
+// details when a call does not name its own.
+func WithDetailAppends(appends ...Append) Option {
+	return func(c *Client) { c.appends = appends }
+}
+
+// WithLogger sets the logger used for request tracing.
+func WithLogger(l *log.Logger) Option {
+	return func(c *Client) { c.logger = l }
+}
+
+// NewClient creates a client for the given API key.
+func NewClient(apiKey string, opts ...Option) (*Client, error) {
+	if apiKey == "" {
+		return nil, ErrMissingAPIKey
+	}
+
+	c := &Client{
+		baseURL:      DefaultBaseURL,
+		imageBaseURL: DefaultImageBaseURL,
+		apiKey:       apiKey,
+		httpClient:   &http.Client{},
+		timeout:      DefaultTimeout,
+		retry:        DefaultRetryPolicy,
+		limiter:      newRateLimiter(DefaultRateLimit, DefaultRateBurst),
+		cache:        NewMemoryCache(DefaultCacheEntries),
+		ttl:          DefaultTTL,
+		appends:      DefaultAppends,
+		logger:       log.Default(),
+		flights:      make(map[string]*flight),
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c, nil
+}
+
+// ImageURL returns the full URL of a TMDB image at the given size bucket
+// ("original", "w500", ...).
+func (c *Client) ImageURL(size string, imagePath string) string {
+	if !strings.HasPrefix(imagePath, "/") {
+		imagePath = "/" + imagePath
+	}
+	return c.imageBaseURL + "/" + size + imagePath
+}
+
+// OpenImage starts downloading a TMDB image. The caller must close the body.
+func (c *Client) OpenImage(ctx context.Context, size string, imagePath string) (*http.Response, error) {
+	if imagePath == "" {
+		return nil, fmt.Errorf("image path is empty")
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.ImageURL(size, imagePath), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("bad status: %s", resp.Status)
+	}
+	return resp, nil
+}
+
+// makeRequest returns the raw body for endpoint, serving it from the
+// response cache when a fresh copy is available.
+func (c *Client) makeRequest(ctx context.Context, endpoint string, params url.Values) ([]byte, error) {
+	ttl := c.ttl(endpoint)
+	cacheable := c.cache != nil && ttl > 0
+
+	key := cacheKey(endpoint, params)
+	if cacheable {
+		if entry, ok := c.cache.Get(key); ok {
+			return entry.Body, nil
+		}
+	}
+
+	return c.coalesce(ctx, key, func(ctx context.Context) ([]byte, error) {
+		body, err := c.fetch(ctx, endpoint, params)
+		if err == nil && cacheable {
+			c.cache.Set(key, Entry{Body: body, Expires: time.Now().Add(ttl)})
+		}
+		return body, err
+	})
+}
+
+// coalesce runs fn once for all concurrent callers asking for the same key
+// and hands each of them the result. The shared call is detached from any
+// one caller's context, so a caller giving up doesn't fail the others; it
+// just stops waiting. The call's own context is cancelled when the last
+// waiting caller gives up, and later callers start a new one. Callers must
+// not modify the returned body.
+func (c *Client) coalesce(ctx context.Context, key string, fn func(ctx context.Context) ([]byte, error)) ([]byte, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	c.flightsMu.Lock()
+	f, ok := c.flights[key]
+	if !ok {
+		flightCtx, cancel := context.WithCancel(context.Background())
+		f = &flight{done: make(chan struct{}), cancel: cancel}
+		c.flights[key] = f
+		go func() {
+			f.body, f.err = fn(flightCtx)
+			c.flightsMu.Lock()
+			if c.flights[key] == f {
+				delete(c.flights, key)
+			}
+			c.flightsMu.Unlock()
+			cancel()
+			close(f.done)
+		}()
+	}
+	f.waiters++
+	c.flightsMu.Unlock()
+
+	select {
+	case <-f.done:
+		return f.body, f.err
+	case <-ctx.Done():
+		c.flightsMu.Lock()
+		f.waiters--
+		if f.waiters == 0 {
+			f.cancel()
+			if c.flights[key] == f {
+				delete(c.flights, key)
+			}
+		}
+		c.flightsMu.Unlock()
+		return nil, ctx.Err()
+	}
+}
+
+// fetch performs a GET against endpoint and returns the raw body.
+// Rate-limited (429), server-side (5xx) and transport failures are retried
+// according to the client's RetryPolicy.
+func (c *Client) fetch(ctx context.Context, endpoint string, params url.Values) ([]byte, error) {
+	query := url.Values{}
+	for k, v := range params {
+		query[k] = v
+	}
+	// Add API key as query parameter for v3 API
+	query.Set("api_key", c.apiKey)
+	requestURL := c.baseURL + endpoint + "?" + query.Encode()
+
+	attempts := c.retry.MaxAttempts
+	if attempts < 1 {
+		attempts = 1
+	}
+
+	var lastErr error
+	for attempt := 1; ; attempt++ {
+		if err := c.limiter.Wait(ctx); err != nil {
+			return nil, err
+		}
+
+		c.logger.Printf("Making request to: %s%s (attempt %d)", c.baseURL, endpoint, attempt)
+		body, header, err := c.doRequest(ctx, requestURL)
+		if err == nil {
+			c.logger.Printf("Successful API response for endpoint %s (length: %d bytes)", endpoint, len(body))
+			return body, nil
+		}
+		lastErr = err
+
+		var apiErr *APIError
+		if errors.As(err, &apiErr) {
+			c.logger.Printf("TMDB API Error Response (Status %d): %s", apiErr.StatusCode, apiErr.Body)
+			if !retryable(apiErr.StatusCode) {
+				return nil, err
+			}
+		} else {
+			c.logger.Printf("Error making request: %v", err)
+		}
+		if ctx.Err() != nil || attempt >= attempts {
+			break
+		}
+
+		delay := c.retry.backoff(attempt)
+		// Honour Retry-After, but no longer than the policy would ever wait
+		if wait := retryAfter(header); wait > 0 {
+			delay = min(wait, c.retry.MaxDelay)
+		}
+		c.logger.Printf("Retrying %s in %s", endpoint, delay)
+		if err := sleep(ctx, delay); err != nil {
+			return nil, err
+		}
+	}
+	return nil, lastErr
+}
+
+// doRequest performs a single attempt. The response header is returned even
+// on failure so that Retry-After can be honoured.
+func (c *Client) doRequest(ctx context.Context, requestURL string) ([]byte, http.Header, error) {
+	if c.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.timeout)
+		defer cancel()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, requestURL, nil)
+	if err != nil {
+		return nil, nil, c.redact(err)
+	}
+	req.Header.Add("accept", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, nil, c.redact(err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, resp.Header, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, resp.Header, &APIError{StatusCode: resp.StatusCode, Body: string(body)}
+	}
+	return body, resp.Header, nil
+}
+
+// redact takes the API key out of the request URL that transport errors
+// quote, so it ends up in neither logs nor responses
+func (c *Client) redact(err error) error {
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		urlErr.URL = strings.ReplaceAll(urlErr.URL, url.QueryEscape(c.apiKey), "REDACTED")
+	}
+	return err
+}
+
+// get fetches endpoint and decodes the JSON body into out.
+func (c *Client) get(ctx context.Context, endpoint string, params url.Values, out interface{}) error {
+	data, err := c.makeRequest(ctx, endpoint, params)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, out); err != nil {
+		c.logger.Printf("Error unmarshaling response for %s: %v", endpoint, err)
+		return err
+	}
+	return nil
+}
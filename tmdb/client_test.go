@@ -0,0 +1,332 @@
+package tmdb
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// newTestClient points a client at a local fake TMDB. Retries are immediate
+// and nothing is rate limited or cached unless opts say otherwise.
+func newTestClient(t *testing.T, handler http.HandlerFunc, opts ...Option) *Client {
+	t.Helper()
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	opts = append([]Option{
+		WithBaseURL(server.URL),
+		WithRetryPolicy(RetryPolicy{MaxAttempts: 3}),
+		WithRateLimit(0, 0),
+		WithCache(nil),
+		WithLogger(log.New(io.Discard, "", 0)),
+	}, opts...)
+	client, err := NewClient("test-key", opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return client
+}
+
+func TestNewClientRequiresAPIKey(t *testing.T) {
+	if _, err := NewClient(""); !errors.Is(err, ErrMissingAPIKey) {
+		t.Fatalf("NewClient(\"\") error = %v, want ErrMissingAPIKey", err)
+	}
+}
+
+func TestMakeRequest(t *testing.T) {
+	var got *http.Request
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		got = r
+		io.WriteString(w, `{"id": 1}`)
+	})
+
+	body, err := client.makeRequest(context.Background(), "/movie/1", url.Values{"language": {"de"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(body) != `{"id": 1}` {
+		t.Errorf("body = %q", body)
+	}
+	if got.URL.Path != "/movie/1" {
+		t.Errorf("path = %q, want /movie/1", got.URL.Path)
+	}
+	if key := got.URL.Query().Get("api_key"); key != "test-key" {
+		t.Errorf("api_key = %q, want test-key", key)
+	}
+	if lang := got.URL.Query().Get("language"); lang != "de" {
+		t.Errorf("language = %q, want de", lang)
+	}
+	if accept := got.Header.Get("Accept"); accept != "application/json" {
+		t.Errorf("Accept = %q, want application/json", accept)
+	}
+}
+
+func TestMakeRequestServesFromCache(t *testing.T) {
+	var hits atomic.Int32
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		io.WriteString(w, `{}`)
+	}, WithCache(NewMemoryCache(10)))
+
+	for i := 0; i < 3; i++ {
+		if _, err := client.makeRequest(context.Background(), "/movie/1", nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// The cache key doesn't include the API key, but does include params
+	if _, err := client.makeRequest(context.Background(), "/movie/1", url.Values{"page": {"2"}}); err != nil {
+		t.Fatal(err)
+	}
+	if n := hits.Load(); n != 2 {
+		t.Errorf("upstream requests = %d, want 2", n)
+	}
+}
+
+func TestMakeRequestDoesNotCacheErrors(t *testing.T) {
+	var hits atomic.Int32
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if hits.Add(1) == 1 {
+			http.Error(w, `{"status_code": 34}`, http.StatusNotFound)
+			return
+		}
+		io.WriteString(w, `{}`)
+	}, WithCache(NewMemoryCache(10)))
+
+	if _, err := client.makeRequest(context.Background(), "/movie/1", nil); err == nil {
+		t.Fatal("first request succeeded, want 404")
+	}
+	if _, err := client.makeRequest(context.Background(), "/movie/1", nil); err != nil {
+		t.Fatalf("second request: %v", err)
+	}
+}
+
+func TestErrorMapping(t *testing.T) {
+	tests := []struct {
+		name     string
+		status   int
+		attempts int32 // upstream requests expected with MaxAttempts 3
+	}{
+		{"not found", http.StatusNotFound, 1},
+		{"unauthorized", http.StatusUnauthorized, 1},
+		{"rate limited", http.StatusTooManyRequests, 3},
+		{"server error", http.StatusBadGateway, 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var hits atomic.Int32
+			client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+				hits.Add(1)
+				w.WriteHeader(tt.status)
+				io.WriteString(w, "nope")
+			})
+
+			_, err := client.makeRequest(context.Background(), "/movie/1", nil)
+			var apiErr *APIError
+			if !errors.As(err, &apiErr) {
+				t.Fatalf("error = %v, want *APIError", err)
+			}
+			if apiErr.StatusCode != tt.status || apiErr.Body != "nope" {
+				t.Errorf("APIError = %+v, want status %d and body %q", apiErr, tt.status, "nope")
+			}
+			if n := hits.Load(); n != tt.attempts {
+				t.Errorf("upstream requests = %d, want %d", n, tt.attempts)
+			}
+		})
+	}
+}
+
+func TestTransportErrorsHideAPIKey(t *testing.T) {
+	var logged strings.Builder
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		// Drop the connection without answering
+		conn, _, err := w.(http.Hijacker).Hijack()
+		if err == nil {
+			conn.Close()
+		}
+	}, WithLogger(log.New(&logged, "", 0)))
+
+	_, err := client.makeRequest(context.Background(), "/movie/1", nil)
+	var urlErr *url.Error
+	if !errors.As(err, &urlErr) {
+		t.Fatalf("error = %v, want *url.Error", err)
+	}
+	if strings.Contains(err.Error(), "test-key") {
+		t.Errorf("error quotes the API key: %v", err)
+	}
+	if !strings.Contains(err.Error(), "api_key=REDACTED") {
+		t.Errorf("error = %v, want the redacted request URL", err)
+	}
+	if strings.Contains(logged.String(), "test-key") {
+		t.Errorf("log quotes the API key:\n%s", logged.String())
+	}
+}
+
+func TestRetrySucceedsAfterTransientError(t *testing.T) {
+	var hits atomic.Int32
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if hits.Add(1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		io.WriteString(w, `{"id": 1}`)
+	})
+
+	body, err := client.makeRequest(context.Background(), "/movie/1", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(body) != `{"id": 1}` {
+		t.Errorf("body = %q", body)
+	}
+}
+
+func TestGetReportsInvalidJSON(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, `{"id": `)
+	})
+
+	if _, err := client.MovieDetails(context.Background(), 1); err == nil {
+		t.Fatal("MovieDetails succeeded on a truncated body")
+	}
+}
+
+// waitForWaiters blocks until n callers are waiting for the shared fetch of
+// key
+func waitForWaiters(t *testing.T, c *Client, key string, n int) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		c.flightsMu.Lock()
+		waiters := 0
+		if f := c.flights[key]; f != nil {
+			waiters = f.waiters
+		}
+		c.flightsMu.Unlock()
+		if waiters == n {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("%d callers waiting for %s, want %d", waiters, key, n)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestCoalesceSharesOneFetch(t *testing.T) {
+	var hits atomic.Int32
+	release := make(chan struct{})
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		<-release
+		io.WriteString(w, `{"id": 1}`)
+	})
+
+	const callers = 10
+	var wg sync.WaitGroup
+	errs := make([]error, callers)
+	bodies := make([][]byte, callers)
+	for i := 0; i < callers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			bodies[i], errs[i] = client.makeRequest(context.Background(), "/movie/1", nil)
+		}(i)
+	}
+	waitForWaiters(t, client, cacheKey("/movie/1", nil), callers)
+	close(release)
+	wg.Wait()
+
+	for i := range errs {
+		if errs[i] != nil || string(bodies[i]) != `{"id": 1}` {
+			t.Errorf("caller %d got %q, %v", i, bodies[i], errs[i])
+		}
+	}
+	if n := hits.Load(); n != 1 {
+		t.Errorf("upstream requests = %d, want 1", n)
+	}
+}
+
+func TestCoalesceCallerGivingUpDoesNotFailOthers(t *testing.T) {
+	release := make(chan struct{})
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		<-release
+		io.WriteString(w, `{}`)
+	})
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := client.makeRequest(context.Background(), "/movie/1", nil)
+		done <- err
+	}()
+	key := cacheKey("/movie/1", nil)
+	waitForWaiters(t, client, key, 1)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	giveUp := make(chan error, 1)
+	go func() {
+		_, err := client.makeRequest(ctx, "/movie/1", nil)
+		giveUp <- err
+	}()
+	waitForWaiters(t, client, key, 2)
+	cancel()
+	if err := <-giveUp; !errors.Is(err, context.Canceled) {
+		t.Errorf("cancelled caller error = %v, want context.Canceled", err)
+	}
+
+	close(release)
+	if err := <-done; err != nil {
+		t.Errorf("waiting caller error = %v, want nil", err)
+	}
+}
+
+func TestCoalesceCancelledOnceEveryCallerGivesUp(t *testing.T) {
+	var hits atomic.Int32
+	upstreamCancelled := make(chan struct{})
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if hits.Add(1) == 1 {
+			<-r.Context().Done()
+			close(upstreamCancelled)
+			return
+		}
+		io.WriteString(w, `{"id": 1}`)
+	}, WithRetryPolicy(RetryPolicy{MaxAttempts: 1}))
+
+	const callers = 3
+	key := cacheKey("/movie/1", nil)
+	ctx, cancel := context.WithCancel(context.Background())
+	errs := make(chan error, callers)
+	for i := 0; i < callers; i++ {
+		go func() {
+			_, err := client.makeRequest(ctx, "/movie/1", nil)
+			errs <- err
+		}()
+	}
+	waitForWaiters(t, client, key, callers)
+	cancel()
+	for i := 0; i < callers; i++ {
+		if err := <-errs; !errors.Is(err, context.Canceled) {
+			t.Errorf("caller error = %v, want context.Canceled", err)
+		}
+	}
+
+	select {
+	case <-upstreamCancelled:
+	case <-time.After(5 * time.Second):
+		t.Fatal("upstream request still running after every caller gave up")
+	}
+
+	// The next caller starts afresh rather than joining the cancelled fetch
+	body, err := client.makeRequest(context.Background(), "/movie/1", nil)
+	if err != nil || string(body) != `{"id": 1}` {
+		t.Errorf("after cancellation got %q, %v", body, err)
+	}
+}
@@ -0,0 +1,27 @@
+package tmdb
+
+import (
+	"context"
+	"fmt"
+)
+
+type CollectionDetails struct {
+	ID           int            `json:"id"`
+	Name         string         `json:"name"`
+	Overview     string         `json:"overview"`
+	PosterPath   string         `json:"poster_path"`
+	BackdropPath string         `json:"backdrop_path"`
+	Parts        []MediaContent `json:"parts"`
+}
+
+// CollectionDetails fetches a collection and the movies that belong to it.
+func (c *Client) CollectionDetails(ctx context.Context, collectionID int) (*CollectionDetails, error) {
+	var response CollectionDetails
+	if err := c.get(ctx, fmt.Sprintf("/collection/%d", collectionID), nil, &response); err != nil {
+		return nil, err
+	}
+	for i := range response.Parts {
+		response.Parts[i].MediaType = MediaTypeMovie
+	}
+	return &response, nil
+}
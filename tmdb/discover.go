@@ -0,0 +1,124 @@
+package tmdb
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// Sort orders understood by DiscoverFilter.Sort.
+const (
+	SortPopularity = "popularity"
+	SortRating     = "rating"
+	SortNewest     = "newest"
+	SortOldest     = "oldest"
+	SortTitle      = "title"
+)
+
+// DiscoverFilter describes a /discover query. Zero values leave the
+// corresponding facet unfiltered.
+type DiscoverFilter struct {
+	Genres       []int
+	YearFrom     int
+	YearTo       int
+	MinRating    float64
+	MinVoteCount int
+	RuntimeMin   int
+	RuntimeMax   int
+	Language     string // ISO 639-1 original language
+	Sort         string
+	Page         int
+}
+
+// params translates the filter to TMDB query parameters. Movies and series
+// name their date and title fields differently.
+func (f DiscoverFilter) params(mediaType string) url.Values {
+	dateField, titleField := "primary_release_date", "original_title"
+	if mediaType == MediaTypeTV {
+		dateField, titleField = "first_air_date", "original_name"
+	}
+
+	params := url.Values{"include_adult": {"false"}}
+	if len(f.Genres) > 0 {
+		ids := make([]string, len(f.Genres))
+		for i, id := range f.Genres {
+			ids[i] = strconv.Itoa(id)
+		}
+		params.Set("with_genres", strings.Join(ids, ","))
+	}
+	if f.YearFrom > 0 {
+		params.Set(dateField+".gte", fmt.Sprintf("%04d-01-01", f.YearFrom))
+	}
+	if f.YearTo > 0 {
+		params.Set(dateField+".lte", fmt.Sprintf("%04d-12-31", f.YearTo))
+	}
+	if f.MinRating > 0 {
+		params.Set("vote_average.gte", strconv.FormatFloat(f.MinRating, 'f', -1, 64))
+	}
+	if f.MinVoteCount > 0 {
+		params.Set("vote_count.gte", strconv.Itoa(f.MinVoteCount))
+	}
+	if f.RuntimeMin > 0 {
+		params.Set("with_runtime.gte", strconv.Itoa(f.RuntimeMin))
+	}
+	if f.RuntimeMax > 0 {
+		params.Set("with_runtime.lte", strconv.Itoa(f.RuntimeMax))
+	}
+	if f.Language != "" {
+		params.Set("with_original_language", f.Language)
+	}
+
+	switch f.Sort {
+	case SortRating:
+		params.Set("sort_by", "vote_average.desc")
+	case SortNewest:
+		params.Set("sort_by", dateField+".desc")
+	case SortOldest:
+		params.Set("sort_by", dateField+".asc")
+	case SortTitle:
+		params.Set("sort_by", titleField+".asc")
+	default:
+		params.Set("sort_by", "popularity.desc")
+	}
+
+	page := f.Page
+	if page < 1 {
+		page = 1
+	}
+	params.Set("page", strconv.Itoa(page))
+	return params
+}
+
+// Discover lists movies or series (mediaType MediaTypeMovie or MediaTypeTV)
+// matching filter. Results carry their MediaType.
+func (c *Client) Discover(ctx context.Context, mediaType string, filter DiscoverFilter) (*Response, error) {
+	if mediaType != MediaTypeMovie && mediaType != MediaTypeTV {
+		return nil, fmt.Errorf("tmdb: cannot discover media type %q", mediaType)
+	}
+
+	var response Response
+	if err := c.get(ctx, "/discover/"+mediaType, filter.params(mediaType), &response); err != nil {
+		return nil, err
+	}
+	for i := range response.Results {
+		response.Results[i].MediaType = mediaType
+	}
+	return &response, nil
+}
+
+// Genres lists the official genres for movies or series.
+func (c *Client) Genres(ctx context.Context, mediaType string) ([]Genre, error) {
+	if mediaType != MediaTypeMovie && mediaType != MediaTypeTV {
+		return nil, fmt.Errorf("tmdb: no genres for media type %q", mediaType)
+	}
+
+	var response struct {
+		Genres []Genre `json:"genres"`
+	}
+	if err := c.get(ctx, "/genre/"+mediaType+"/list", nil, &response); err != nil {
+		return nil, err
+	}
+	return response.Genres, nil
+}
@@ -0,0 +1,88 @@
+package tmdb
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strconv"
+)
+
+func (c *Client) TrendingMovies(ctx context.Context) (*Response, error) {
+	var response Response
+	if err := c.get(ctx, "/trending/movie/week", nil, &response); err != nil {
+		return nil, err
+	}
+	c.logSample("trending movie", &response)
+	return &response, nil
+}
+
+func (c *Client) PopularMovies(ctx context.Context) (*Response, error) {
+	var response Response
+	if err := c.get(ctx, "/movie/popular", nil, &response); err != nil {
+		return nil, err
+	}
+	c.logSample("popular movie", &response)
+	return &response, nil
+}
+
+func (c *Client) UpcomingMovies(ctx context.Context) (*Response, error) {
+	var response Response
+	if err := c.get(ctx, "/movie/upcoming", nil, &response); err != nil {
+		return nil, err
+	}
+	c.logSample("upcoming movie", &response)
+	return &response, nil
+}
+
+// UpcomingMoviesInRegion fetches a page of upcoming movies, with release
+// dates local to region.
+func (c *Client) UpcomingMoviesInRegion(ctx context.Context, region string, page int) (*Response, error) {
+	params := url.Values{"region": {region}, "page": {strconv.Itoa(page)}}
+	var response Response
+	if err := c.get(ctx, "/movie/upcoming", params, &response); err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+func (c *Client) RecommendedMovies(ctx context.Context, movieID int) (*Response, error) {
+	var response Response
+	if err := c.get(ctx, fmt.Sprintf("/movie/%d/recommendations", movieID), nil, &response); err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// SimilarMovies fetches movies TMDB considers similar to movieID, going by
+// genres and keywords rather than what other users watched.
+func (c *Client) SimilarMovies(ctx context.Context, movieID int) (*Response, error) {
+	var response Response
+	if err := c.get(ctx, fmt.Sprintf("/movie/%d/similar", movieID), nil, &response); err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// MovieDetails fetches a movie together with the given sub-resources, or the
+// client's configured appends when none are given.
+func (c *Client) MovieDetails(ctx context.Context, movieID int, appends ...Append) (*DetailedContent, error) {
+	if len(appends) == 0 {
+		appends = c.appends
+	}
+
+	var response DetailedContent
+	if err := c.get(ctx, fmt.Sprintf("/movie/%d", movieID), appendParams(appends, false), &response); err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// logSample logs the first item of a list response for debugging.
+func (c *Client) logSample(label string, response *Response) {
+	if len(response.Results) == 0 {
+		return
+	}
+	first := response.Results[0]
+	c.logger.Printf("Sample %s: ID=%d, Name=%s, Title=%s, PosterPath=%s, ReleaseDate=%s, FirstAirDate=%s",
+		label, first.ID, first.Name, first.Title, first.PosterPath, first.ReleaseDate, first.FirstAirDate)
+}
@@ -0,0 +1,89 @@
+package tmdb
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+)
+
+type Person struct {
+	ID                 int           `json:"id"`
+	Name               string        `json:"name"`
+	Biography          string        `json:"biography"`
+	Birthday           string        `json:"birthday"`
+	Deathday           string        `json:"deathday"`
+	PlaceOfBirth       string        `json:"place_of_birth"`
+	ProfilePath        string        `json:"profile_path"`
+	KnownForDepartment string        `json:"known_for_department"`
+	AlsoKnownAs        []string      `json:"also_known_as"`
+	Popularity         float64       `json:"popularity"`
+	IMDbID             string        `json:"imdb_id"`
+	Homepage           string        `json:"homepage"`
+	CombinedCredits    PersonCredits `json:"combined_credits"`
+	Images             PersonImages  `json:"images"`
+}
+
+// PersonCredits lists every movie and series a person worked on.
+type PersonCredits struct {
+	Cast []PersonCredit `json:"cast"`
+	Crew []PersonCredit `json:"crew"`
+}
+
+// PersonCredit is one entry of a filmography. Character is set for cast
+// credits, Department and Job for crew credits.
+type PersonCredit struct {
+	ID           int     `json:"id"`
+	MediaType    string  `json:"media_type"`
+	Title        string  `json:"title"`
+	Name         string  `json:"name"`
+	Character    string  `json:"character"`
+	Department   string  `json:"department"`
+	Job          string  `json:"job"`
+	ReleaseDate  string  `json:"release_date"`
+	FirstAirDate string  `json:"first_air_date"`
+	PosterPath   string  `json:"poster_path"`
+	VoteAverage  float64 `json:"vote_average"`
+	VoteCount    int     `json:"vote_count"`
+	Popularity   float64 `json:"popularity"`
+	EpisodeCount int     `json:"episode_count"`
+}
+
+// DisplayTitle returns the movie title or series name.
+func (p PersonCredit) DisplayTitle() string {
+	if p.Title != "" {
+		return p.Title
+	}
+	return p.Name
+}
+
+// Date returns the release or first air date, whichever applies.
+func (p PersonCredit) Date() string {
+	if p.ReleaseDate != "" {
+		return p.ReleaseDate
+	}
+	return p.FirstAirDate
+}
+
+type PersonImages struct {
+	Profiles []Image `json:"profiles"`
+}
+
+type Image struct {
+	FilePath    string  `json:"file_path"`
+	Width       int     `json:"width"`
+	Height      int     `json:"height"`
+	AspectRatio float64 `json:"aspect_ratio"`
+	VoteAverage float64 `json:"vote_average"`
+}
+
+// PersonDetails fetches a person with their combined movie and TV credits
+// and profile images.
+func (c *Client) PersonDetails(ctx context.Context, personID int) (*Person, error) {
+	params := url.Values{"append_to_response": {"combined_credits,images"}}
+
+	var response Person
+	if err := c.get(ctx, fmt.Sprintf("/person/%d", personID), params, &response); err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
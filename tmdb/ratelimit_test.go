@@ -0,0 +1,70 @@
+package tmdb
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestRateLimiterReserve(t *testing.T) {
+	l := newRateLimiter(10, 3)
+
+	// The full burst is available straight away
+	for i := 0; i < 3; i++ {
+		if d := l.reserve(); d != 0 {
+			t.Fatalf("reserve %d within burst = %s, want 0", i, d)
+		}
+	}
+	// Then each token costs a tenth of a second more than the last
+	tests := []time.Duration{100 * time.Millisecond, 200 * time.Millisecond, 300 * time.Millisecond}
+	for i, want := range tests {
+		d := l.reserve()
+		if d < want-10*time.Millisecond || d > want {
+			t.Errorf("reserve %d past burst = %s, want about %s", i, d, want)
+		}
+	}
+}
+
+func TestRateLimiterRefills(t *testing.T) {
+	l := newRateLimiter(10, 1)
+	l.reserve()
+	// Pretend the last token was taken a second ago
+	l.lastFill = l.lastFill.Add(-time.Second)
+	if d := l.reserve(); d != 0 {
+		t.Errorf("reserve after refill = %s, want 0", d)
+	}
+	// Refills never exceed the burst
+	l.lastFill = l.lastFill.Add(-time.Hour)
+	l.reserve()
+	if d := l.reserve(); d == 0 {
+		t.Error("second reserve after a long idle = 0, want a wait with burst 1")
+	}
+}
+
+func TestRateLimiterWait(t *testing.T) {
+	tests := []struct {
+		name    string
+		limiter *rateLimiter
+	}{
+		{"nil", nil},
+		{"disabled", newRateLimiter(0, 0)},
+	}
+	for _, tt := range tests {
+		for i := 0; i < 100; i++ {
+			if err := tt.limiter.Wait(context.Background()); err != nil {
+				t.Fatalf("%s limiter Wait = %v", tt.name, err)
+			}
+		}
+	}
+
+	l := newRateLimiter(1, 1)
+	if err := l.Wait(context.Background()); err != nil {
+		t.Fatalf("first Wait = %v", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := l.Wait(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Wait past the budget = %v, want context.DeadlineExceeded", err)
+	}
+}
@@ -0,0 +1,40 @@
+package tmdb
+
+import (
+	"context"
+	"net/url"
+	"strconv"
+)
+
+// Search runs a full-text query. mediaType selects /search/movie, /search/tv
+// or /search/person; any other value searches all three through /search/multi.
+// Results always carry their MediaType.
+func (c *Client) Search(ctx context.Context, query string, mediaType string, page int) (*Response, error) {
+	endpoint := "/search/multi"
+	switch mediaType {
+	case MediaTypeMovie, MediaTypeTV, MediaTypePerson:
+		endpoint = "/search/" + mediaType
+	default:
+		mediaType = ""
+	}
+
+	if page < 1 {
+		page = 1
+	}
+	params := url.Values{
+		"query":         {query},
+		"page":          {strconv.Itoa(page)},
+		"include_adult": {"false"},
+	}
+
+	var response Response
+	if err := c.get(ctx, endpoint, params, &response); err != nil {
+		return nil, err
+	}
+	if mediaType != "" {
+		for i := range response.Results {
+			response.Results[i].MediaType = mediaType
+		}
+	}
+	return &response, nil
+}
@@ -0,0 +1,98 @@
+package tmdb
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+)
+
+func (c *Client) TrendingSeries(ctx context.Context) (*Response, error) {
+	var response Response
+	if err := c.get(ctx, "/trending/tv/week", nil, &response); err != nil {
+		return nil, err
+	}
+	c.logSample("trending series", &response)
+	return &response, nil
+}
+
+func (c *Client) PopularSeries(ctx context.Context) (*Response, error) {
+	var response Response
+	if err := c.get(ctx, "/tv/popular", nil, &response); err != nil {
+		return nil, err
+	}
+	c.logSample("popular series", &response)
+	return &response, nil
+}
+
+func (c *Client) RecommendedSeries(ctx context.Context, seriesID int) (*Response, error) {
+	var response Response
+	if err := c.get(ctx, fmt.Sprintf("/tv/%d/recommendations", seriesID), nil, &response); err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// SimilarSeries fetches series TMDB considers similar to seriesID, going by
+// genres and keywords rather than what other users watched.
+func (c *Client) SimilarSeries(ctx context.Context, seriesID int) (*Response, error) {
+	var response Response
+	if err := c.get(ctx, fmt.Sprintf("/tv/%d/similar", seriesID), nil, &response); err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// SeriesDetails fetches a series together with the given sub-resources, or
+// the client's configured appends when none are given. Aggregate credits are
+// flattened into Credits so callers can treat movies and series alike.
+func (c *Client) SeriesDetails(ctx context.Context, seriesID int, appends ...Append) (*DetailedContent, error) {
+	if len(appends) == 0 {
+		appends = c.appends
+	}
+
+	var response DetailedContent
+	if err := c.get(ctx, fmt.Sprintf("/tv/%d", seriesID), appendParams(appends, true), &response); err != nil {
+		return nil, err
+	}
+	if response.AggregateCredits != nil {
+		response.Credits = response.AggregateCredits.Flatten()
+	}
+	return &response, nil
+}
+
+func (c *Client) SeasonDetails(ctx context.Context, seriesID int, seasonNumber int) (*SeasonDetails, error) {
+	var response SeasonDetails
+	if err := c.get(ctx, fmt.Sprintf("/tv/%d/season/%d", seriesID, seasonNumber), nil, &response); err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// EpisodeDetails fetches one episode of a series with its still images.
+func (c *Client) EpisodeDetails(ctx context.Context, seriesID, seasonNumber, episodeNumber int) (*EpisodeDetails, error) {
+	var response EpisodeDetails
+	params := url.Values{"append_to_response": {"images"}}
+	endpoint := fmt.Sprintf("/tv/%d/season/%d/episode/%d", seriesID, seasonNumber, episodeNumber)
+	if err := c.get(ctx, endpoint, params, &response); err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// AiringTodaySeries lists series with an episode airing today.
+func (c *Client) AiringTodaySeries(ctx context.Context) (*Response, error) {
+	var response Response
+	if err := c.get(ctx, "/tv/airing_today", nil, &response); err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// OnTheAirSeries lists series with an episode airing in the next seven days.
+func (c *Client) OnTheAirSeries(ctx context.Context) (*Response, error) {
+	var response Response
+	if err := c.get(ctx, "/tv/on_the_air", nil, &response); err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
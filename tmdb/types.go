@@ -0,0 +1,368 @@
+package tmdb
+
+import "encoding/json"
+
+type MediaContent struct {
+	ID           int     `json:"id"`
+	Name         string  `json:"name"`
+	Title        string  `json:"title"`
+	Overview     string  `json:"overview"`
+	PosterPath   string  `json:"poster_path"`
+	BackdropPath string  `json:"backdrop_path"`
+	VoteAverage  float64 `json:"vote_average"`
+	ReleaseDate  string  `json:"release_date,omitempty"`
+	FirstAirDate string  `json:"first_air_date,omitempty"`
+	MediaType    string  `json:"media_type"`
+	GenreIDs     []int   `json:"genre_ids,omitempty"`
+
+	// Set on person results from multi-search
+	ProfilePath        string         `json:"profile_path,omitempty"`
+	KnownForDepartment string         `json:"known_for_department,omitempty"`
+	KnownFor           []MediaContent `json:"known_for,omitempty"`
+}
+
+// Media types as reported in MediaContent.MediaType.
+const (
+	MediaTypeMovie  = "movie"
+	MediaTypeTV     = "tv"
+	MediaTypePerson = "person"
+)
+
+// Response is a page of media results as returned by list endpoints.
+type Response struct {
+	Page         int            `json:"page"`
+	Results      []MediaContent `json:"results"`
+	TotalPages   int            `json:"total_pages"`
+	TotalResults int            `json:"total_results"`
+}
+
+type DetailedContent struct {
+	ID                  int                 `json:"id"`
+	Name                string              `json:"name"`
+	Title               string              `json:"title"`
+	Overview            string              `json:"overview"`
+	PosterPath          string              `json:"poster_path"`
+	BackdropPath        string              `json:"backdrop_path"`
+	VoteAverage         float64             `json:"vote_average"`
+	Genres              []Genre             `json:"genres"`
+	Tagline             string              `json:"tagline"`
+	Status              string              `json:"status"`
+	OriginalLanguage    string              `json:"original_language"`
+	ProductionCountries []ProductionCountry `json:"production_countries"`
+	ProductionCompanies []ProductionCompany `json:"production_companies"`
+	Networks            []Network           `json:"networks,omitempty"`
+	NumberOfSeasons     int                 `json:"number_of_seasons,omitempty"`
+	Seasons             []SeasonSummary     `json:"seasons,omitempty"`
+	LastEpisodeToAir    *Episode            `json:"last_episode_to_air,omitempty"`
+	NextEpisodeToAir    *Episode            `json:"next_episode_to_air,omitempty"`
+	Runtime             int                 `json:"runtime,omitempty"`
+	CreatedBy           []CreatedBy         `json:"created_by,omitempty"`
+	ReleaseDate         string              `json:"release_date,omitempty"`
+	FirstAirDate        string              `json:"first_air_date,omitempty"`
+	VoteCount           int                 `json:"vote_count"`
+	Popularity          float64             `json:"popularity"`
+	Revenue             int64               `json:"revenue"`
+	Budget              int64               `json:"budget"`
+	BelongsToCollection *Collection         `json:"belongs_to_collection"`
+	Credits             Credits             `json:"credits"`
+	AggregateCredits    *AggregateCredits   `json:"aggregate_credits,omitempty"`
+	Keywords            Keywords            `json:"keywords"`
+	Videos              Videos              `json:"videos"`
+	ReleaseDates        ReleaseDates        `json:"release_dates"`
+	ContentRatings      ContentRatings      `json:"content_ratings"`
+	ExternalIDs         ExternalIDs         `json:"external_ids"`
+	WatchProviders      WatchProviders      `json:"watch/providers"`
+}
+
+type Genre struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+type Network struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+type ProductionCountry struct {
+	ISO31661 string `json:"iso_3166_1"`
+	Name     string `json:"name"`
+}
+
+type CreatedBy struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+type ProductionCompany struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+type Collection struct {
+	ID           int    `json:"id"`
+	Name         string `json:"name"`
+	PosterPath   string `json:"poster_path"`
+	BackdropPath string `json:"backdrop_path"`
+}
+
+type Credits struct {
+	Cast []CastMember `json:"cast"`
+	Crew []CrewMember `json:"crew"`
+}
+
+type CastMember struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+	Role string `json:"character"`
+}
+
+type CrewMember struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+	Job  string `json:"job"`
+}
+
+// AggregateCredits is the series-wide cast and crew of a TV show.
+type AggregateCredits struct {
+	Cast []AggregateCastMember `json:"cast"`
+	Crew []AggregateCrewMember `json:"crew"`
+}
+
+type AggregateCastMember struct {
+	ID                int    `json:"id"`
+	Name              string `json:"name"`
+	TotalEpisodeCount int    `json:"total_episode_count"`
+	Roles             []struct {
+		Character    string `json:"character"`
+		EpisodeCount int    `json:"episode_count"`
+	} `json:"roles"`
+}
+
+type AggregateCrewMember struct {
+	ID                int    `json:"id"`
+	Name              string `json:"name"`
+	Department        string `json:"department"`
+	TotalEpisodeCount int    `json:"total_episode_count"`
+	Jobs              []struct {
+		Job          string `json:"job"`
+		EpisodeCount int    `json:"episode_count"`
+	} `json:"jobs"`
+}
+
+// Flatten converts aggregate credits to the per-title shape. Cast members
+// keep the role they played in the most episodes; crew members get an entry
+// for each of their jobs.
+func (a *AggregateCredits) Flatten() Credits {
+	credits := Credits{
+		Cast: make([]CastMember, 0, len(a.Cast)),
+		Crew: make([]CrewMember, 0, len(a.Crew)),
+	}
+	for _, c := range a.Cast {
+		member := CastMember{ID: c.ID, Name: c.Name}
+		best := -1
+		for _, r := range c.Roles {
+			if r.EpisodeCount > best {
+				member.Role, best = r.Character, r.EpisodeCount
+			}
+		}
+		credits.Cast = append(credits.Cast, member)
+	}
+	for _, c := range a.Crew {
+		for _, j := range c.Jobs {
+			credits.Crew = append(credits.Crew, CrewMember{ID: c.ID, Name: c.Name, Job: j.Job})
+		}
+	}
+	return credits
+}
+
+type Keywords struct {
+	Keywords []Keyword `json:"keywords"`
+}
+
+// UnmarshalJSON accepts both the movie shape ({"keywords": [...]}) and the
+// series shape ({"results": [...]}).
+func (k *Keywords) UnmarshalJSON(data []byte) error {
+	var raw struct {
+		Keywords []Keyword `json:"keywords"`
+		Results  []Keyword `json:"results"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	k.Keywords = raw.Keywords
+	if k.Keywords == nil {
+		k.Keywords = raw.Results
+	}
+	return nil
+}
+
+type Keyword struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+// SeasonSummary is a season as listed in a series' details.
+type SeasonSummary struct {
+	ID           int     `json:"id"`
+	Name         string  `json:"name"`
+	Overview     string  `json:"overview"`
+	PosterPath   string  `json:"poster_path"`
+	AirDate      string  `json:"air_date"`
+	EpisodeCount int     `json:"episode_count"`
+	SeasonNumber int     `json:"season_number"`
+	VoteAverage  float64 `json:"vote_average"`
+}
+
+type SeasonDetails struct {
+	ID           int       `json:"id"`
+	Name         string    `json:"name"`
+	Overview     string    `json:"overview"`
+	PosterPath   string    `json:"poster_path"`
+	AirDate      string    `json:"air_date"`
+	Episodes     []Episode `json:"episodes"`
+	SeasonNumber int       `json:"season_number"`
+}
+
+type Episode struct {
+	ID            int     `json:"id"`
+	Name          string  `json:"name"`
+	Overview      string  `json:"overview"`
+	AirDate       string  `json:"air_date"`
+	EpisodeNumber int     `json:"episode_number"`
+	SeasonNumber  int     `json:"season_number"`
+	StillPath     string  `json:"still_path"`
+	VoteAverage   float64 `json:"vote_average"`
+	VoteCount     int     `json:"vote_count"`
+	Runtime       int     `json:"runtime"`
+}
+
+// EpisodeDetails is a single episode with its guest stars, crew and stills.
+type EpisodeDetails struct {
+	Episode
+	GuestStars []CastMember `json:"guest_stars"`
+	Crew       []CrewMember `json:"crew"`
+	Images     struct {
+		Stills []Image `json:"stills"`
+	} `json:"images"`
+}
+
+type Videos struct {
+	Results []Video `json:"results"`
+}
+
+type Video struct {
+	ID          string `json:"id"`
+	Key         string `json:"key"`
+	Name        string `json:"name"`
+	Site        string `json:"site"`
+	Size        int    `json:"size"`
+	Type        string `json:"type"`
+	Official    bool   `json:"official"`
+	ISO6391     string `json:"iso_639_1"`
+	ISO31661    string `json:"iso_3166_1"`
+	PublishedAt string `json:"published_at"`
+}
+
+// ReleaseDates holds a movie's per-country releases and certifications.
+type ReleaseDates struct {
+	Results []CountryReleaseDates `json:"results"`
+}
+
+type CountryReleaseDates struct {
+	ISO31661     string        `json:"iso_3166_1"`
+	ReleaseDates []ReleaseDate `json:"release_dates"`
+}
+
+// Release types as defined by TMDB.
+const (
+	ReleasePremiere = iota + 1
+	ReleaseTheatricalLimited
+	ReleaseTheatrical
+	ReleaseDigital
+	ReleasePhysical
+	ReleaseTV
+)
+
+type ReleaseDate struct {
+	Certification string `json:"certification"`
+	ISO6391       string `json:"iso_639_1"`
+	Note          string `json:"note"`
+	ReleaseDate   string `json:"release_date"`
+	Type          int    `json:"type"`
+}
+
+// ForCountry returns a movie's releases in country.
+func (r ReleaseDates) ForCountry(country string) []ReleaseDate {
+	for _, c := range r.Results {
+		if c.ISO31661 == country {
+			return c.ReleaseDates
+		}
+	}
+	return nil
+}
+
+// Certification returns the first non-empty certification for country.
+func (r ReleaseDates) Certification(country string) string {
+	for _, c := range r.Results {
+		if c.ISO31661 != country {
+			continue
+		}
+		for _, d := range c.ReleaseDates {
+			if d.Certification != "" {
+				return d.Certification
+			}
+		}
+	}
+	return ""
+}
+
+// ContentRatings holds a series' per-country age ratings.
+type ContentRatings struct {
+	Results []ContentRating `json:"results"`
+}
+
+type ContentRating struct {
+	ISO31661 string `json:"iso_3166_1"`
+	Rating   string `json:"rating"`
+}
+
+// Rating returns the rating for country, or "" when there is none.
+func (r ContentRatings) Rating(country string) string {
+	for _, c := range r.Results {
+		if c.ISO31661 == country {
+			return c.Rating
+		}
+	}
+	return ""
+}
+
+type ExternalIDs struct {
+	IMDbID      string `json:"imdb_id"`
+	TVDBID      int    `json:"tvdb_id"`
+	WikidataID  string `json:"wikidata_id"`
+	FacebookID  string `json:"facebook_id"`
+	InstagramID string `json:"instagram_id"`
+	TwitterID   string `json:"twitter_id"`
+}
+
+// WatchProviders maps a country code to where a title can be watched there.
+type WatchProviders struct {
+	Results map[string]WatchProviderRegion `json:"results"`
+}
+
+type WatchProviderRegion struct {
+	Link     string          `json:"link"`
+	Flatrate []WatchProvider `json:"flatrate"`
+	Rent     []WatchProvider `json:"rent"`
+	Buy      []WatchProvider `json:"buy"`
+	Free     []WatchProvider `json:"free"`
+	Ads      []WatchProvider `json:"ads"`
+}
+
+type WatchProvider struct {
+	ProviderID      int    `json:"provider_id"`
+	ProviderName    string `json:"provider_name"`
+	LogoPath        string `json:"logo_path"`
+	DisplayPriority int    `json:"display_priority"`
+}
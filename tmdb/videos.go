@@ -0,0 +1,56 @@
+package tmdb
+
+import "sort"
+
+// Video hosts TMDB links to that can be embedded.
+const (
+	SiteYouTube = "YouTube"
+	SiteVimeo   = "Vimeo"
+)
+
+// EmbedURL returns an autoplaying player URL, or "" for unsupported sites.
+func (v Video) EmbedURL() string {
+	switch v.Site {
+	case SiteYouTube:
+		return "https://www.youtube-nocookie.com/embed/" + v.Key + "?autoplay=1&rel=0"
+	case SiteVimeo:
+		return "https://player.vimeo.com/video/" + v.Key + "?autoplay=1"
+	default:
+		return ""
+	}
+}
+
+// BestTrailer picks the video to show behind a "Watch Trailer" button:
+// an embeddable trailer or teaser, preferring official uploads, then the UI
+// language, then the "Trailer" type, then the newest. It returns nil when
+// there is nothing suitable.
+func (v Videos) BestTrailer(language string) *Video {
+	candidates := make([]Video, 0, len(v.Results))
+	for _, video := range v.Results {
+		if video.EmbedURL() == "" || video.Key == "" {
+			continue
+		}
+		if video.Type != "Trailer" && video.Type != "Teaser" {
+			continue
+		}
+		candidates = append(candidates, video)
+	}
+	if len(candidates) == 0 {
+		return nil
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		a, b := candidates[i], candidates[j]
+		if a.Official != b.Official {
+			return a.Official
+		}
+		if (a.ISO6391 == language) != (b.ISO6391 == language) {
+			return a.ISO6391 == language
+		}
+		if (a.Type == "Trailer") != (b.Type == "Trailer") {
+			return a.Type == "Trailer"
+		}
+		return a.PublishedAt > b.PublishedAt
+	})
+	return &candidates[0]
+}
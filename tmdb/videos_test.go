@@ -0,0 +1,94 @@
+package tmdb
+
+import "testing"
+
+func TestBestTrailer(t *testing.T) {
+	video := func(id, site, kind string, official bool, language, published string) Video {
+		return Video{ID: id, Key: "k" + id, Site: site, Type: kind, Official: official, ISO6391: language, PublishedAt: published}
+	}
+	tests := []struct {
+		name     string
+		videos   []Video
+		language string
+		want     string // ID of the chosen video, "" for none
+	}{
+		{"nothing", nil, "en", ""},
+		{
+			"official over unofficial",
+			[]Video{
+				video("fan", SiteYouTube, "Trailer", false, "en", "2024-02-01"),
+				video("studio", SiteYouTube, "Trailer", true, "en", "2024-01-01"),
+			},
+			"en", "studio",
+		},
+		{
+			"trailer over teaser",
+			[]Video{
+				video("teaser", SiteYouTube, "Teaser", true, "en", "2024-02-01"),
+				video("trailer", SiteYouTube, "Trailer", true, "en", "2024-01-01"),
+			},
+			"en", "trailer",
+		},
+		{
+			"preferred language over type",
+			[]Video{
+				video("en", SiteYouTube, "Trailer", true, "en", "2024-01-01"),
+				video("fr", SiteYouTube, "Teaser", true, "fr", "2024-01-01"),
+			},
+			"fr", "fr",
+		},
+		{
+			"official over preferred language",
+			[]Video{
+				video("fr", SiteYouTube, "Trailer", false, "fr", "2024-01-01"),
+				video("en", SiteYouTube, "Trailer", true, "en", "2024-01-01"),
+			},
+			"fr", "en",
+		},
+		{
+			"newest of equals",
+			[]Video{
+				video("old", SiteYouTube, "Trailer", true, "en", "2023-01-01T00:00:00.000Z"),
+				video("new", SiteYouTube, "Trailer", true, "en", "2024-01-01T00:00:00.000Z"),
+			},
+			"en", "new",
+		},
+		{
+			"Vimeo is embeddable",
+			[]Video{video("vimeo", SiteVimeo, "Trailer", true, "en", "2024-01-01")},
+			"en", "vimeo",
+		},
+		{
+			"other sites are skipped",
+			[]Video{
+				video("dailymotion", "Dailymotion", "Trailer", true, "en", "2024-02-01"),
+				video("youtube", SiteYouTube, "Teaser", false, "de", "2023-01-01"),
+			},
+			"en", "youtube",
+		},
+		{
+			"only trailers and teasers",
+			[]Video{
+				video("clip", SiteYouTube, "Clip", true, "en", "2024-01-01"),
+				video("featurette", SiteYouTube, "Featurette", true, "en", "2024-01-01"),
+			},
+			"en", "",
+		},
+		{
+			"missing key",
+			[]Video{{ID: "nokey", Site: SiteYouTube, Type: "Trailer", Official: true}},
+			"en", "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Videos{Results: tt.videos}.BestTrailer(tt.language)
+			switch {
+			case got == nil && tt.want != "":
+				t.Errorf("BestTrailer() = nil, want %s", tt.want)
+			case got != nil && got.ID != tt.want:
+				t.Errorf("BestTrailer() = %s, want %q", got.ID, tt.want)
+			}
+		})
+	}
+}
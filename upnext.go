@@ -0,0 +1,209 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/url"
+	"sort"
+	"sync"
+	"time"
+
+	"cineseer/components"
+	"cineseer/tmdb"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// upNext is where a user stands with a series they are watching
+type upNext struct {
+	Entry       components.UpNextEntry
+	LastWatched time.Time // when the user last marked an episode watched, for ordering
+}
+
+// seriesEnded reports whether TMDB considers a series over
+func seriesEnded(status string) bool {
+	return status == "Ended" || status == "Canceled"
+}
+
+// upNextEntry works out where a user stands with a series
+func upNextEntry(ctx context.Context, client *tmdb.Client, userID int64, seriesID int, today time.Time) (upNext, error) {
+	series, err := client.SeriesDetails(ctx, seriesID)
+	if err != nil {
+		return upNext{}, err
+	}
+	episodes, err := userStore.WatchedEpisodes(ctx, userID, seriesID)
+	if err != nil {
+		return upNext{}, err
+	}
+	var result upNext
+	for _, episode := range episodes {
+		if episode.WatchedAt.After(result.LastWatched) {
+			result.LastWatched = episode.WatchedAt
+		}
+	}
+
+	entry := components.UpNextEntry{
+		SeriesID:   series.ID,
+		SeriesName: series.Name,
+		HasPoster:  series.PosterPath != "",
+		Ended:      seriesEnded(series.Status),
+	}
+	if next := series.NextEpisodeToAir; next != nil {
+		entry.Upcoming = fmt.Sprintf("S%02dE%02d", next.SeasonNumber, next.EpisodeNumber)
+		if next.AirDate != "" {
+			entry.Upcoming += " on " + formatDate(next.AirDate)
+		}
+	}
+
+	err = scanEpisodes(&entry, series.Seasons, episodes, today, func(season int) (*tmdb.SeasonDetails, error) {
+		return client.SeasonDetails(ctx, seriesID, season)
+	})
+	if err != nil {
+		return upNext{}, err
+	}
+	result.Entry = entry
+	return result, nil
+}
+
+// scanEpisodes finds the first aired episode of a series the user hasn't
+// watched, counts the aired episodes left and sets entry's state. Seasons are
+// loaded in order with loadSeason, skipping specials and seasons the user
+// has watched in full, and the scan stops at the first episode yet to air or
+// without an air date.
+func scanEpisodes(entry *components.UpNextEntry, seasons []tmdb.SeasonSummary, episodes []WatchedEpisode, today time.Time, loadSeason func(season int) (*tmdb.SeasonDetails, error)) error {
+	watched := make(map[episodeKey]bool, len(episodes))
+	watchedInSeason := make(map[int]int)
+	for _, episode := range episodes {
+		watched[episodeKey{episode.Season, episode.Episode}] = true
+		watchedInSeason[episode.Season]++
+	}
+
+	seasons = append([]tmdb.SeasonSummary(nil), seasons...)
+	sort.Slice(seasons, func(i, j int) bool { return seasons[i].SeasonNumber < seasons[j].SeasonNumber })
+scan:
+	for _, summary := range seasons {
+		// Specials don't count towards progress
+		if summary.SeasonNumber == 0 {
+			continue
+		}
+		if watchedInSeason[summary.SeasonNumber] >= summary.EpisodeCount {
+			continue
+		}
+		season, err := loadSeason(summary.SeasonNumber)
+		if err != nil {
+			return err
+		}
+		for _, episode := range season.Episodes {
+			if aired, ok := tmdbDate(episode.AirDate); !ok || aired.After(today) {
+				break scan
+			}
+			if watched[episodeKey{summary.SeasonNumber, episode.EpisodeNumber}] {
+				continue
+			}
+			entry.Remaining++
+			if entry.Season == 0 {
+				entry.Season = summary.SeasonNumber
+				entry.Episode = episode.EpisodeNumber
+				entry.EpisodeName = episode.Name
+				entry.AirDate = formatDate(episode.AirDate)
+				entry.HasStill = episode.StillPath != ""
+			}
+		}
+	}
+
+	switch {
+	case entry.Season != 0:
+		entry.State = components.UpNextAvailable
+	case entry.Ended:
+		entry.State = components.UpNextFinished
+	default:
+		entry.State = components.UpNextWaiting
+	}
+	return nil
+}
+
+// upNextEntries works out Up Next for every series a user is watching,
+// continuing with the series watched most recently first
+func upNextEntries(ctx context.Context, client *tmdb.Client, userID int64) ([]components.UpNextEntry, error) {
+	items, err := userStore.Watchlist(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	var watching []WatchlistItem
+	for _, item := range items {
+		if item.Kind == "series" && item.Status == components.WatchWatching {
+			watching = append(watching, item)
+		}
+	}
+
+	today := calendarToday()
+	results := make([]upNext, len(watching))
+	ok := make([]bool, len(watching))
+	var wg sync.WaitGroup
+	for i, item := range watching {
+		wg.Add(1)
+		go func(i int, item WatchlistItem) {
+			defer wg.Done()
+			result, err := upNextEntry(ctx, client, userID, item.MediaID, today)
+			if err != nil {
+				log.Printf("Error getting up next for series %d: %v", item.MediaID, err)
+				return
+			}
+			// Series without watched episodes sort by when they were started
+			if result.LastWatched.IsZero() {
+				result.LastWatched = item.UpdatedAt
+			}
+			results[i], ok[i] = result, true
+		}(i, item)
+	}
+	wg.Wait()
+
+	var found []upNext
+	for i, result := range results {
+		if ok[i] {
+			found = append(found, result)
+		}
+	}
+	sort.SliceStable(found, func(i, j int) bool { return found[i].LastWatched.After(found[j].LastWatched) })
+
+	entries := make([]components.UpNextEntry, len(found))
+	for i, result := range found {
+		entries[i] = result.Entry
+	}
+	return entries, nil
+}
+
+// upNextRow lists the episodes ready to watch next, for the home page
+func upNextRow(ctx context.Context, client *tmdb.Client, userID int64) ([]components.UpNextEntry, error) {
+	entries, err := upNextEntries(ctx, client, userID)
+	if err != nil {
+		return nil, err
+	}
+	var available []components.UpNextEntry
+	for _, entry := range entries {
+		if entry.State == components.UpNextAvailable {
+			available = append(available, entry)
+		}
+	}
+	return available, nil
+}
+
+// setupUpNext serves the Up Next page. It does nothing without a user store.
+func setupUpNext(app *fiber.App, client *tmdb.Client, basePath string) {
+	if userStore == nil {
+		return
+	}
+
+	app.Get(basePath+"/up-next", func(c *fiber.Ctx) error {
+		user := currentUser(c)
+		if user == nil {
+			return c.Redirect(basePath + "/login?" + url.Values{"next": {c.OriginalURL()}}.Encode())
+		}
+		entries, err := upNextEntries(c.Context(), client, user.ID)
+		if err != nil {
+			return c.Status(500).SendString(err.Error())
+		}
+		return render(c, basePath, components.UpNextPage(components.UpNextProps{Entries: entries}))
+	})
+}
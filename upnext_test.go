@@ -0,0 +1,184 @@
+package main
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"cineseer/components"
+	"cineseer/tmdb"
+)
+
+// fakeSeason builds a season whose episodes aired on the given dates, in
+// order; an empty date means TMDB has none yet
+func fakeSeason(number int, airDates ...string) *tmdb.SeasonDetails {
+	season := &tmdb.SeasonDetails{SeasonNumber: number}
+	for i, date := range airDates {
+		season.Episodes = append(season.Episodes, tmdb.Episode{
+			EpisodeNumber: i + 1,
+			SeasonNumber:  number,
+			Name:          "Episode " + string(rune('A'+i)),
+			AirDate:       date,
+		})
+	}
+	return season
+}
+
+func watchedEpisodes(keys ...episodeKey) []WatchedEpisode {
+	var episodes []WatchedEpisode
+	for _, key := range keys {
+		episodes = append(episodes, WatchedEpisode{SeriesID: 1, Season: key.Season, Episode: key.Episode})
+	}
+	return episodes
+}
+
+func TestScanEpisodes(t *testing.T) {
+	today := time.Date(2026, 10, 16, 0, 0, 0, 0, time.UTC)
+	seasons := map[int]*tmdb.SeasonDetails{
+		0: fakeSeason(0, "2020-01-01", "2020-06-01"),
+		1: fakeSeason(1, "2024-01-01", "2024-01-08", "2024-01-15"),
+		2: fakeSeason(2, "2025-03-01", "2025-03-08", "2026-10-16", "2026-10-23"),
+		3: fakeSeason(3, "", ""),
+		4: fakeSeason(4, "2024-01-01", "", "2024-01-15"),
+	}
+	summaries := []tmdb.SeasonSummary{
+		// TMDB lists specials first; the scan must not rely on the order
+		{SeasonNumber: 2, EpisodeCount: 4},
+		{SeasonNumber: 0, EpisodeCount: 2},
+		{SeasonNumber: 3, EpisodeCount: 2},
+		{SeasonNumber: 1, EpisodeCount: 3},
+	}
+
+	tests := []struct {
+		name      string
+		ended     bool
+		seasons   []tmdb.SeasonSummary
+		watched   []WatchedEpisode
+		state     string
+		season    int
+		episode   int
+		remaining int
+		loaded    []int
+	}{
+		{
+			name:    "nothing watched",
+			seasons: summaries, state: components.UpNextAvailable,
+			season: 1, episode: 1, remaining: 6,
+			// Today's episode has aired; next week's stops the scan
+			loaded: []int{1, 2},
+		},
+		{
+			name:    "specials don't count",
+			seasons: summaries, watched: watchedEpisodes(episodeKey{0, 1}),
+			state: components.UpNextAvailable, season: 1, episode: 1, remaining: 6,
+			loaded: []int{1, 2},
+		},
+		{
+			name:    "partially watched season",
+			seasons: summaries, watched: watchedEpisodes(episodeKey{1, 1}, episodeKey{1, 2}),
+			state: components.UpNextAvailable, season: 1, episode: 3, remaining: 4,
+			loaded: []int{1, 2},
+		},
+		{
+			name:    "skipped episode comes first",
+			seasons: summaries, watched: watchedEpisodes(episodeKey{1, 1}, episodeKey{1, 3}),
+			state: components.UpNextAvailable, season: 1, episode: 2, remaining: 4,
+			loaded: []int{1, 2},
+		},
+		{
+			name:    "watched seasons aren't loaded",
+			seasons: summaries, watched: watchedEpisodes(episodeKey{1, 1}, episodeKey{1, 2}, episodeKey{1, 3}, episodeKey{2, 1}),
+			state: components.UpNextAvailable, season: 2, episode: 2, remaining: 2,
+			loaded: []int{2},
+		},
+		{
+			name:    "caught up with a running series",
+			seasons: summaries,
+			watched: watchedEpisodes(episodeKey{1, 1}, episodeKey{1, 2}, episodeKey{1, 3},
+				episodeKey{2, 1}, episodeKey{2, 2}, episodeKey{2, 3}),
+			state:  components.UpNextWaiting,
+			loaded: []int{2},
+		},
+		{
+			name: "episodes without air dates stop the scan",
+			seasons: []tmdb.SeasonSummary{
+				{SeasonNumber: 1, EpisodeCount: 3},
+				{SeasonNumber: 3, EpisodeCount: 2},
+			},
+			watched: watchedEpisodes(episodeKey{1, 1}, episodeKey{1, 2}, episodeKey{1, 3}),
+			state:   components.UpNextWaiting,
+			loaded:  []int{3},
+		},
+		{
+			name:    "a missing air date mid-season",
+			seasons: []tmdb.SeasonSummary{{SeasonNumber: 4, EpisodeCount: 3}},
+			state:   components.UpNextAvailable, season: 4, episode: 1, remaining: 1,
+			loaded: []int{4},
+		},
+		{
+			name:    "finished an ended series",
+			ended:   true,
+			seasons: []tmdb.SeasonSummary{{SeasonNumber: 1, EpisodeCount: 3}},
+			watched: watchedEpisodes(episodeKey{1, 1}, episodeKey{1, 2}, episodeKey{1, 3}),
+			state:   components.UpNextFinished,
+		},
+		{
+			name:    "an ended series with episodes left",
+			ended:   true,
+			seasons: []tmdb.SeasonSummary{{SeasonNumber: 1, EpisodeCount: 3}},
+			state:   components.UpNextAvailable, season: 1, episode: 1, remaining: 3,
+			loaded: []int{1},
+		},
+		{
+			name:    "no seasons yet",
+			seasons: nil,
+			state:   components.UpNextWaiting,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entry := components.UpNextEntry{Ended: tt.ended}
+			var loaded []int
+			err := scanEpisodes(&entry, tt.seasons, tt.watched, today, func(season int) (*tmdb.SeasonDetails, error) {
+				loaded = append(loaded, season)
+				return seasons[season], nil
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if entry.State != tt.state || entry.Season != tt.season || entry.Episode != tt.episode || entry.Remaining != tt.remaining {
+				t.Errorf("got %s S%dE%d with %d left, want %s S%dE%d with %d left",
+					entry.State, entry.Season, entry.Episode, entry.Remaining, tt.state, tt.season, tt.episode, tt.remaining)
+			}
+			if !reflect.DeepEqual(loaded, tt.loaded) {
+				t.Errorf("loaded seasons %v, want %v", loaded, tt.loaded)
+			}
+		})
+	}
+}
+
+func TestScanEpisodesDescribesNextEpisode(t *testing.T) {
+	season := fakeSeason(1, "2024-01-01", "2024-01-08")
+	season.Episodes[1].StillPath = "/still.jpg"
+	entry := components.UpNextEntry{}
+	err := scanEpisodes(&entry, []tmdb.SeasonSummary{{SeasonNumber: 1, EpisodeCount: 2}}, watchedEpisodes(episodeKey{1, 1}),
+		time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC),
+		func(int) (*tmdb.SeasonDetails, error) { return season, nil })
+	if err != nil {
+		t.Fatal(err)
+	}
+	if entry.EpisodeName != "Episode B" || entry.AirDate != "January 8, 2024" || !entry.HasStill {
+		t.Errorf("entry = %+v", entry)
+	}
+}
+
+func TestScanEpisodesReportsSeasonErrors(t *testing.T) {
+	boom := errors.New("boom")
+	entry := components.UpNextEntry{}
+	err := scanEpisodes(&entry, []tmdb.SeasonSummary{{SeasonNumber: 1, EpisodeCount: 2}}, nil, time.Now(),
+		func(int) (*tmdb.SeasonDetails, error) { return nil, boom })
+	if !errors.Is(err, boom) {
+		t.Errorf("error = %v, want %v", err, boom)
+	}
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// Errors a UserStore returns for lookups and conflicts
+var (
+	ErrUserNotFound = errors.New("user not found")
+	ErrUserExists   = errors.New("username is taken")
+
+	ErrNotOnWatchlist = errors.New("not on the watchlist")
+)
+
+// User is a local account, or one created for a reverse proxy's user
+type User struct {
+	ID           int64
+	Username     string
+	PasswordHash string // empty for accounts that can only sign in through the proxy
+	CreatedAt    time.Time
+}
+
+// UserStore keeps accounts and their login sessions. Sessions are looked up
+// by a hash of the cookie token, so a leaked database can't be replayed.
+type UserStore interface {
+	CreateUser(ctx context.Context, username, passwordHash string) (*User, error)
+	UserByName(ctx context.Context, username string) (*User, error)
+	UserByID(ctx context.Context, id int64) (*User, error)
+	CountUsers(ctx context.Context) (int, error)
+
+	CreateSession(ctx context.Context, tokenHash string, userID int64, expires time.Time) error
+	// SessionUser returns the owner of an unexpired session
+	SessionUser(ctx context.Context, tokenHash string, now time.Time) (*User, error)
+	DeleteSession(ctx context.Context, tokenHash string) error
+	DeleteExpiredSessions(ctx context.Context, now time.Time) error
+
+	WatchlistStore
+
+	Close() error
+}
@@ -0,0 +1,284 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"cineseer/components"
+	"cineseer/tmdb"
+)
+
+// Defaults for the background warmer, overridable with WARM_INTERVAL and
+// WARM_WORKERS
+const (
+	defaultWarmInterval = time.Hour
+	defaultWarmWorkers  = 4
+
+	// Only the most recent seasons of each series are warmed; older ones are
+	// fetched on demand
+	warmSeasonLimit = 3
+)
+
+// Warm phases, run in priority order
+const (
+	warmPhaseHome    = "home"
+	warmPhaseDetails = "details"
+	warmPhaseSeasons = "seasons"
+)
+
+// backgroundWarmer is the scheduler started from main, nil when disabled
+var backgroundWarmer *warmer
+
+// WarmerRun summarises one completed warm run
+type WarmerRun struct {
+	StartedAt  time.Time `json:"started_at"`
+	FinishedAt time.Time `json:"finished_at"`
+	Duration   string    `json:"duration"`
+	Warmed     int       `json:"warmed"`
+	Failed     int       `json:"failed"`
+}
+
+// WarmerStatus is reported by /api/warmer/status
+type WarmerStatus struct {
+	Interval string     `json:"interval"`
+	Workers  int        `json:"workers"`
+	Running  bool       `json:"running"`
+	Phase    string     `json:"phase,omitempty"`
+	Done     int        `json:"done"`
+	Total    int        `json:"total"`
+	Failed   int        `json:"failed"`
+	Started  time.Time  `json:"started_at"`
+	NextRun  time.Time  `json:"next_run"`
+	LastRun  *WarmerRun `json:"last_run,omitempty"`
+}
+
+// warmer keeps the home page, the details and images of everything on it,
+// and recent seasons of its series cached, independent of page views. Work
+// runs through a fixed pool of workers so a run can't flood TMDB.
+type warmer struct {
+	client   *tmdb.Client
+	interval time.Duration
+	workers  int
+
+	mu     sync.Mutex
+	status WarmerStatus
+}
+
+func newWarmer(client *tmdb.Client, interval time.Duration, workers int) *warmer {
+	if workers < 1 {
+		workers = 1
+	}
+	return &warmer{
+		client:   client,
+		interval: interval,
+		workers:  workers,
+		status: WarmerStatus{
+			Interval: interval.String(),
+			Workers:  workers,
+		},
+	}
+}
+
+// Start runs the warmer now and then every interval until ctx is done
+func (w *warmer) Start(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(w.interval)
+		defer ticker.Stop()
+		for {
+			w.run(ctx)
+			w.mu.Lock()
+			w.status.NextRun = time.Now().Add(w.interval)
+			w.mu.Unlock()
+
+			select {
+			case <-ticker.C:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+}
+
+// Status returns a snapshot of the current progress and the last run
+func (w *warmer) Status() WarmerStatus {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	status := w.status
+	if status.LastRun != nil {
+		last := *status.LastRun
+		status.LastRun = &last
+	}
+	return status
+}
+
+func (w *warmer) run(ctx context.Context) {
+	started := time.Now()
+	w.mu.Lock()
+	w.status.Running = true
+	w.status.Started = started
+	w.status.Done, w.status.Total, w.status.Failed = 0, 0, 0
+	w.mu.Unlock()
+	log.Printf("Starting background warm run")
+
+	defer func() {
+		w.mu.Lock()
+		defer w.mu.Unlock()
+		finished := time.Now()
+		w.status.LastRun = &WarmerRun{
+			StartedAt:  started,
+			FinishedAt: finished,
+			Duration:   finished.Sub(started).Round(time.Millisecond).String(),
+			Warmed:     w.status.Done - w.status.Failed,
+			Failed:     w.status.Failed,
+		}
+		w.status.Running = false
+		w.status.Phase = ""
+		log.Printf("Background warm run finished in %s: %d warmed, %d failed",
+			w.status.LastRun.Duration, w.status.LastRun.Warmed, w.status.LastRun.Failed)
+	}()
+
+	// Home rows first, since every visitor sees them
+	w.runPhase(ctx, warmPhaseHome, []func(ctx context.Context) error{
+		func(ctx context.Context) error {
+			return (<-startHomePageRefresh(w.client)).Err
+		},
+	})
+
+	homePageMutex.RLock()
+	data := homePageCache
+	homePageMutex.RUnlock()
+	if data == nil {
+		return
+	}
+
+	// Then the details and images behind each card, once per title
+	seen := make(map[string]bool)
+	var items []tmdb.MediaContent
+	for _, section := range homeSections {
+		for _, content := range *section.field(data) {
+			key := mediaImageKey(content, "poster")
+			if id := key.MediaType + "/" + key.ID; !seen[id] {
+				seen[id] = true
+				items = append(items, content)
+			}
+		}
+	}
+	var detailTasks []func(ctx context.Context) error
+	for _, content := range items {
+		content := content
+		detailTasks = append(detailTasks, func(ctx context.Context) error {
+			return warmMediaContent(ctx, w.client, content)
+		})
+	}
+	w.runPhase(ctx, warmPhaseDetails, detailTasks)
+
+	// Then the latest seasons of each series, whose details are cached by now
+	var seasonTasks []func(ctx context.Context) error
+	for _, content := range items {
+		if isMovie(content) {
+			continue
+		}
+		details, err := w.client.SeriesDetails(ctx, content.ID)
+		if err != nil {
+			continue
+		}
+		for season := details.NumberOfSeasons; season > 0 && season > details.NumberOfSeasons-warmSeasonLimit; season-- {
+			id, season := content.ID, season
+			seasonTasks = append(seasonTasks, func(ctx context.Context) error {
+				_, err := w.client.SeasonDetails(ctx, id, season)
+				return err
+			})
+		}
+	}
+	w.runPhase(ctx, warmPhaseSeasons, seasonTasks)
+}
+
+// runPhase runs tasks on the worker pool and waits for them to finish.
+// Once ctx is done no more are started, and those left are taken back off
+// the total.
+func (w *warmer) runPhase(ctx context.Context, phase string, tasks []func(ctx context.Context) error) {
+	w.mu.Lock()
+	w.status.Phase = phase
+	w.status.Total += len(tasks)
+	w.mu.Unlock()
+
+	queue := make(chan func(ctx context.Context) error)
+	var wg sync.WaitGroup
+	for i := 0; i < w.workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for task := range queue {
+				err := task(ctx)
+				w.mu.Lock()
+				w.status.Done++
+				if err != nil {
+					w.status.Failed++
+				}
+				w.mu.Unlock()
+				if err != nil {
+					log.Printf("Error warming %s: %v", phase, err)
+				}
+			}
+		}()
+	}
+
+	dispatched := 0
+dispatch:
+	for _, task := range tasks {
+		if ctx.Err() != nil {
+			break
+		}
+		select {
+		case queue <- task:
+			dispatched++
+		case <-ctx.Done():
+			break dispatch
+		}
+	}
+	close(queue)
+	wg.Wait()
+
+	// Tasks never handed to a worker were not warmed, nor did they fail
+	if skipped := len(tasks) - dispatched; skipped > 0 {
+		w.mu.Lock()
+		w.status.Total -= skipped
+		w.mu.Unlock()
+	}
+}
+
+// warmMediaContent caches the details, card poster and backdrop of a movie
+// or series
+func warmMediaContent(ctx context.Context, client *tmdb.Client, content tmdb.MediaContent) error {
+	var err error
+	if isMovie(content) {
+		_, err = client.MovieDetails(ctx, content.ID)
+	} else {
+		_, err = client.SeriesDetails(ctx, content.ID)
+	}
+	if err != nil {
+		return fmt.Errorf("details for %d: %w", content.ID, err)
+	}
+
+	// The poster at the size and format media cards ask for
+	cardKey := mediaImageKey(content, "poster")
+	cardKey.Width = components.CardPosterWidth
+	cardKey.Format = preferredImageFormat()
+	if content.PosterPath != "" && !imageStore.Fresh(cardKey.filename()) {
+		if err := cacheImage(ctx, client, content.PosterPath, cardKey); err != nil {
+			return fmt.Errorf("poster for %d: %w", content.ID, err)
+		}
+	}
+
+	backdropKey := mediaImageKey(content, "backdrop")
+	backdropKey.Format = preferredImageFormat()
+	if content.BackdropPath != "" && !imageStore.Fresh(backdropKey.filename()) {
+		if err := cacheImage(ctx, client, content.BackdropPath, backdropKey); err != nil {
+			return fmt.Errorf("backdrop for %d: %w", content.ID, err)
+		}
+	}
+	return nil
+}
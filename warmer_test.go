@@ -0,0 +1,26 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestRunPhaseCountsOnlyDispatchedTasks(t *testing.T) {
+	w := newWarmer(nil, time.Hour, 2)
+	var ran int
+	task := func(ctx context.Context) error {
+		ran++
+		return nil
+	}
+
+	w.runPhase(context.Background(), warmPhaseHome, []func(ctx context.Context) error{task})
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	w.runPhase(ctx, warmPhaseDetails, []func(ctx context.Context) error{task, task, task})
+
+	status := w.Status()
+	if ran != 1 || status.Done != 1 || status.Total != 1 {
+		t.Errorf("ran %d, status %d/%d; want only the task started before cancellation counted", ran, status.Done, status.Total)
+	}
+}
@@ -0,0 +1,346 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"cineseer/components"
+	"cineseer/tmdb"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// WatchlistItem is a movie or series on a user's watchlist. Its title, year
+// and overview are copied from TMDB when it is added so the watchlist can be
+// listed without fetching every title.
+type WatchlistItem struct {
+	Kind      string // "movie" or "series"
+	MediaID   int
+	Status    string // components.WatchWant, WatchWatching or WatchWatched
+	Title     string
+	Year      string
+	Overview  string
+	AddedAt   time.Time
+	UpdatedAt time.Time
+}
+
+// WatchedEpisode is an episode of a series a user has marked watched
+type WatchedEpisode struct {
+	SeriesID  int
+	Season    int
+	Episode   int
+	WatchedAt time.Time
+}
+
+// WatchlistStore keeps each user's watchlist and watched episodes
+type WatchlistStore interface {
+	// SetWatchStatus adds an item or updates its status and details
+	SetWatchStatus(ctx context.Context, userID int64, item WatchlistItem) error
+	RemoveFromWatchlist(ctx context.Context, userID int64, kind string, mediaID int) error
+	// Watchlist lists a user's items, most recently updated first
+	Watchlist(ctx context.Context, userID int64) ([]WatchlistItem, error)
+	// WatchlistItem returns one title on a user's watchlist, or
+	// ErrNotOnWatchlist
+	WatchlistItem(ctx context.Context, userID int64, kind string, mediaID int) (*WatchlistItem, error)
+
+	SetEpisodeWatched(ctx context.Context, userID int64, episode WatchedEpisode, watched bool) error
+	// WatchedEpisodes lists the watched episodes of a series in airing order
+	WatchedEpisodes(ctx context.Context, userID int64, seriesID int) ([]WatchedEpisode, error)
+}
+
+func validWatchStatus(status string) bool {
+	return status == components.WatchWant || status == components.WatchWatching || status == components.WatchWatched
+}
+
+// watchStatuses maps the titles on a user's watchlist to their status, by
+// components.WatchKey
+func watchStatuses(ctx context.Context, userID int64) map[string]string {
+	items, err := userStore.Watchlist(ctx, userID)
+	if err != nil {
+		log.Printf("Error getting watchlist of user %d: %v", userID, err)
+		return nil
+	}
+	statuses := make(map[string]string, len(items))
+	for _, item := range items {
+		statuses[components.WatchKey(item.Kind, item.MediaID)] = item.Status
+	}
+	return statuses
+}
+
+type episodeKey struct{ Season, Episode int }
+
+// watchedEpisodeSet returns the episodes of a series a user has watched
+func watchedEpisodeSet(ctx context.Context, userID int64, seriesID int) (map[episodeKey]bool, error) {
+	episodes, err := userStore.WatchedEpisodes(ctx, userID, seriesID)
+	if err != nil {
+		return nil, err
+	}
+	watched := make(map[episodeKey]bool, len(episodes))
+	for _, episode := range episodes {
+		watched[episodeKey{episode.Season, episode.Episode}] = true
+	}
+	return watched, nil
+}
+
+// trackSeason marks the watched episodes of a season when the viewer has
+// its series on their watchlist
+func trackSeason(c *fiber.Ctx, props *components.SeasonProps) {
+	user := currentUser(c)
+	if user == nil {
+		return
+	}
+	if viewer(c).WatchStatus("series", props.SeriesID) == "" {
+		return
+	}
+	watched, err := watchedEpisodeSet(c.Context(), user.ID, props.SeriesID)
+	if err != nil {
+		log.Printf("Error getting watched episodes of series %d: %v", props.SeriesID, err)
+		return
+	}
+	props.Tracked = true
+	for i := range props.Episodes {
+		props.Episodes[i].Watched = watched[episodeKey{props.SeasonNumber, props.Episodes[i].EpisodeNumber}]
+	}
+}
+
+// trackEpisode is trackSeason for an episode page
+func trackEpisode(c *fiber.Ctx, props *components.EpisodePageProps) {
+	user := currentUser(c)
+	if user == nil {
+		return
+	}
+	if viewer(c).WatchStatus("series", props.SeriesID) == "" {
+		return
+	}
+	watched, err := watchedEpisodeSet(c.Context(), user.ID, props.SeriesID)
+	if err != nil {
+		log.Printf("Error getting watched episodes of series %d: %v", props.SeriesID, err)
+		return
+	}
+	props.Tracked = true
+	props.Watched = watched[episodeKey{props.SeasonNumber, props.EpisodeNumber}]
+}
+
+// watchlistCard shows a watchlist item as a media card
+func watchlistCard(item WatchlistItem) components.MediaCardProps {
+	return components.MediaCardProps{
+		ID:       item.MediaID,
+		Title:    item.Title,
+		Year:     item.Year,
+		Overview: item.Overview,
+		Type:     item.Kind,
+	}
+}
+
+// watchlistProps filters and sorts a watchlist for the watchlist page
+func watchlistProps(items []WatchlistItem, status, kind, order string) components.WatchlistProps {
+	props := components.WatchlistProps{Status: status, Type: kind, Sort: order, Total: len(items)}
+
+	var matches []WatchlistItem
+	for _, item := range items {
+		if (status == "" || item.Status == status) && (kind == "" || item.Kind == kind) {
+			matches = append(matches, item)
+		}
+	}
+	// Items come most recently updated first
+	switch order {
+	case components.WatchlistSortAdded:
+		sort.SliceStable(matches, func(i, j int) bool { return matches[i].AddedAt.After(matches[j].AddedAt) })
+	case components.WatchlistSortTitle:
+		sort.SliceStable(matches, func(i, j int) bool {
+			return strings.ToLower(matches[i].Title) < strings.ToLower(matches[j].Title)
+		})
+	case components.WatchlistSortYear:
+		sort.SliceStable(matches, func(i, j int) bool { return matches[i].Year > matches[j].Year })
+	}
+
+	props.Items = make([]components.MediaCardProps, len(matches))
+	for i, item := range matches {
+		props.Items[i] = watchlistCard(item)
+	}
+	return props
+}
+
+// watchlistQuery reads the filters of a watchlist request
+func watchlistQuery(c *fiber.Ctx) (string, string, string, error) {
+	status := c.Query("status")
+	if status != "" && !validWatchStatus(status) {
+		return "", "", "", fmt.Errorf("invalid status %q", status)
+	}
+	kind := c.Query("type")
+	if kind != "" && kind != "movie" && kind != "series" {
+		return "", "", "", fmt.Errorf("invalid type %q", kind)
+	}
+	order := c.Query("sort", components.WatchlistSortRecent)
+	switch order {
+	case components.WatchlistSortRecent, components.WatchlistSortAdded, components.WatchlistSortTitle, components.WatchlistSortYear:
+	default:
+		return "", "", "", fmt.Errorf("invalid sort %q", order)
+	}
+	return status, kind, order, nil
+}
+
+// watchlistHomeRow lists what a user wants to watch or is watching, for the
+// home page
+func watchlistHomeRow(ctx context.Context, userID int64) ([]components.MediaCardProps, error) {
+	items, err := userStore.Watchlist(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	// Series in progress first, then the rest most recently updated first
+	sort.SliceStable(items, func(i, j int) bool {
+		return items[i].Status == components.WatchWatching && items[j].Status != components.WatchWatching
+	})
+	var cards []components.MediaCardProps
+	for _, item := range items {
+		if item.Status != components.WatchWatched && len(cards) < 20 {
+			cards = append(cards, watchlistCard(item))
+		}
+	}
+	return cards, nil
+}
+
+// watchlistDetails fetches what a watchlist item keeps of a title
+func watchlistDetails(ctx context.Context, client *tmdb.Client, kind string, id int) (WatchlistItem, error) {
+	var details *tmdb.DetailedContent
+	var err error
+	if kind == "movie" {
+		details, err = client.MovieDetails(ctx, id)
+	} else {
+		details, err = client.SeriesDetails(ctx, id)
+	}
+	if err != nil {
+		return WatchlistItem{}, err
+	}
+	props := detailedContentToProps(details, kind, "en")
+	return WatchlistItem{Kind: kind, MediaID: id, Title: props.Title, Year: props.Year, Overview: props.Overview}, nil
+}
+
+// setupWatchlist serves the watchlist page and the htmx toggles that change
+// it. It does nothing without a user store.
+func setupWatchlist(app *fiber.App, api fiber.Router, client *tmdb.Client, basePath string) {
+	if userStore == nil {
+		return
+	}
+
+	app.Get(basePath+"/watchlist", func(c *fiber.Ctx) error {
+		user := currentUser(c)
+		if user == nil {
+			return c.Redirect(basePath + "/login?" + url.Values{"next": {c.OriginalURL()}}.Encode())
+		}
+		status, kind, order, err := watchlistQuery(c)
+		if err != nil {
+			return c.Status(400).SendString(err.Error())
+		}
+		items, err := userStore.Watchlist(c.Context(), user.ID)
+		if err != nil {
+			return c.Status(500).SendString(err.Error())
+		}
+		return render(c, basePath, components.WatchlistPage(watchlistProps(items, status, kind, order)))
+	})
+
+	api.Get("/watchlist", func(c *fiber.Ctx) error {
+		user := currentUser(c)
+		if user == nil {
+			return c.Status(401).SendString("<div class='error'>Sign in to see your watchlist</div>")
+		}
+		status, kind, order, err := watchlistQuery(c)
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+		items, err := userStore.Watchlist(c.Context(), user.ID)
+		if err != nil {
+			return c.Status(500).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+		return render(c, basePath, components.WatchlistResults(watchlistProps(items, status, kind, order)))
+	})
+
+	// Set or clear the status of a title; an empty status removes it
+	api.Post("/watchlist/:kind/:id", func(c *fiber.Ctx) error {
+		user := currentUser(c)
+		if user == nil {
+			return c.Status(401).SendString("Sign in to use your watchlist")
+		}
+		kind := c.Params("kind")
+		id, err := c.ParamsInt("id")
+		if err != nil || (kind != "movie" && kind != "series") {
+			return c.Status(400).SendString("Invalid title")
+		}
+		status := c.FormValue("status")
+		if status != "" && !validWatchStatus(status) {
+			return c.Status(400).SendString("Invalid status")
+		}
+
+		if status == "" {
+			err = userStore.RemoveFromWatchlist(c.Context(), user.ID, kind, id)
+		} else {
+			var item WatchlistItem
+			item, err = watchlistDetails(c.Context(), client, kind, id)
+			if err != nil {
+				log.Printf("Error getting details of %s %d for watchlist: %v", kind, id, err)
+				return c.Status(502).SendString("Error getting title details")
+			}
+			item.Status = status
+			err = userStore.SetWatchStatus(c.Context(), user.ID, item)
+		}
+		if err != nil {
+			return c.Status(500).SendString(err.Error())
+		}
+		log.Printf("User %s set %s %d to %q", user.Username, kind, id, status)
+		return render(c, basePath, components.WatchToggle(kind, id, status, c.FormValue("compact") != ""))
+	})
+
+	// Mark an episode of a series on the watchlist watched or unwatched
+	api.Post("/watchlist/series/:id/season/:season/episode/:episode", func(c *fiber.Ctx) error {
+		user := currentUser(c)
+		if user == nil {
+			return c.Status(401).SendString("Sign in to use your watchlist")
+		}
+		id, err := c.ParamsInt("id")
+		season, seasonErr := c.ParamsInt("season")
+		episode, episodeErr := c.ParamsInt("episode")
+		if err != nil || seasonErr != nil || episodeErr != nil {
+			return c.Status(400).SendString("Invalid episode")
+		}
+		watched := c.FormValue("watched") == "true"
+
+		item, err := userStore.WatchlistItem(c.Context(), user.ID, "series", id)
+		if errors.Is(err, ErrNotOnWatchlist) {
+			return c.Status(409).SendString("Add the series to your watchlist first")
+		}
+		if err != nil {
+			return c.Status(500).SendString(err.Error())
+		}
+		ep := WatchedEpisode{SeriesID: id, Season: season, Episode: episode, WatchedAt: time.Now()}
+		if err := userStore.SetEpisodeWatched(c.Context(), user.ID, ep, watched); err != nil {
+			return c.Status(500).SendString(err.Error())
+		}
+		// Watching an episode starts the series
+		if watched && item.Status == components.WatchWant {
+			item.Status = components.WatchWatching
+			if err := userStore.SetWatchStatus(c.Context(), user.ID, *item); err != nil {
+				log.Printf("Error starting series %d for %s: %v", id, user.Username, err)
+			}
+		}
+		// The Up Next page swaps in the series' card for the episode after
+		if c.FormValue("up_next") != "" {
+			next, err := upNextEntry(c.Context(), client, user.ID, id, calendarToday())
+			if err != nil {
+				log.Printf("Error getting up next for series %d: %v", id, err)
+				return c.Status(502).SendString("Error getting the next episode")
+			}
+			return render(c, basePath, components.UpNextCard(next.Entry))
+		}
+		return render(c, basePath, components.EpisodeWatchToggle(id, season, episode, watched))
+	})
+}
@@ -0,0 +1,215 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"cineseer/components"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestSQLiteWatchlist(t *testing.T) {
+	store := newTestStore(t)
+	ctx := context.Background()
+	user, err := store.CreateUser(ctx, "alice", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := store.CreateUser(ctx, "bob", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	andor := WatchlistItem{Kind: "series", MediaID: 83867, Status: components.WatchWant, Title: "Andor", Year: "2022"}
+	alien := WatchlistItem{Kind: "movie", MediaID: 348, Status: components.WatchWatched, Title: "Alien", Year: "1979"}
+	for _, item := range []WatchlistItem{andor, alien} {
+		if err := store.SetWatchStatus(ctx, user.ID, item); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Another user's watchlist is separate, even for the same title
+	if err := store.SetWatchStatus(ctx, other.ID, alien); err != nil {
+		t.Fatal(err)
+	}
+
+	// Pretend both were added a while ago, then update Andor
+	if _, err := store.db.Exec("UPDATE watchlist SET added_at = 1000, updated_at = 1000 WHERE user_id = ?", user.ID); err != nil {
+		t.Fatal(err)
+	}
+	andor.Status, andor.Overview = components.WatchWatching, "A rebel"
+	if err := store.SetWatchStatus(ctx, user.ID, andor); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := store.WatchlistItem(ctx, user.ID, "series", andor.MediaID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Status != components.WatchWatching || got.Overview != "A rebel" || got.Title != "Andor" {
+		t.Errorf("updated item = %+v", got)
+	}
+	if got.AddedAt.Unix() != 1000 || got.UpdatedAt.Unix() <= 1000 {
+		t.Errorf("updated item added %s, updated %s; want the add time kept", got.AddedAt, got.UpdatedAt)
+	}
+	if _, err := store.WatchlistItem(ctx, user.ID, "movie", andor.MediaID); !errors.Is(err, ErrNotOnWatchlist) {
+		t.Errorf("WatchlistItem of the wrong kind = %v, want ErrNotOnWatchlist", err)
+	}
+
+	items, err := store.Watchlist(ctx, user.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(items) != 2 || items[0].MediaID != andor.MediaID {
+		t.Errorf("Watchlist = %+v, want Andor (updated last) then Alien", items)
+	}
+
+	if err := store.RemoveFromWatchlist(ctx, user.ID, "movie", alien.MediaID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.WatchlistItem(ctx, user.ID, "movie", alien.MediaID); !errors.Is(err, ErrNotOnWatchlist) {
+		t.Errorf("WatchlistItem after removal = %v, want ErrNotOnWatchlist", err)
+	}
+	if _, err := store.WatchlistItem(ctx, other.ID, "movie", alien.MediaID); err != nil {
+		t.Errorf("removal reached another user's watchlist: %v", err)
+	}
+}
+
+func TestSQLiteWatchedEpisodes(t *testing.T) {
+	store := newTestStore(t)
+	ctx := context.Background()
+	user, err := store.CreateUser(ctx, "alice", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	watch := func(season, episode int, watched bool) {
+		t.Helper()
+		ep := WatchedEpisode{SeriesID: 1, Season: season, Episode: episode, WatchedAt: time.Unix(int64(season*100+episode), 0)}
+		if err := store.SetEpisodeWatched(ctx, user.ID, ep, watched); err != nil {
+			t.Fatal(err)
+		}
+	}
+	watch(2, 1, true)
+	watch(1, 2, true)
+	watch(1, 1, true)
+	watch(1, 1, true) // already watched: keeps the first time
+	watch(1, 2, false)
+	watch(3, 1, false) // never watched
+	if err := store.SetEpisodeWatched(ctx, user.ID, WatchedEpisode{SeriesID: 2, Season: 1, Episode: 1, WatchedAt: time.Now()}, true); err != nil {
+		t.Fatal(err)
+	}
+
+	episodes, err := store.WatchedEpisodes(ctx, user.ID, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []WatchedEpisode{
+		{SeriesID: 1, Season: 1, Episode: 1, WatchedAt: time.Unix(101, 0)},
+		{SeriesID: 1, Season: 2, Episode: 1, WatchedAt: time.Unix(201, 0)},
+	}
+	if !reflect.DeepEqual(episodes, want) {
+		t.Errorf("WatchedEpisodes = %+v, want %+v", episodes, want)
+	}
+}
+
+func TestWatchlistProps(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2026, 1, d, 0, 0, 0, 0, time.UTC) }
+	// Most recently updated first, as the store lists them
+	items := []WatchlistItem{
+		{Kind: "series", MediaID: 1, Status: components.WatchWatching, Title: "andor", Year: "2022", AddedAt: day(3)},
+		{Kind: "movie", MediaID: 2, Status: components.WatchWatched, Title: "Alien", Year: "1979", AddedAt: day(1)},
+		{Kind: "movie", MediaID: 3, Status: components.WatchWant, Title: "Zodiac", Year: "2007", AddedAt: day(4)},
+		{Kind: "series", MediaID: 4, Status: components.WatchWant, Title: "Babylon 5", Year: "1993", AddedAt: day(2)},
+	}
+	tests := []struct {
+		name   string
+		status string
+		kind   string
+		order  string
+		want   []int
+	}{
+		{"everything", "", "", components.WatchlistSortRecent, []int{1, 2, 3, 4}},
+		{"by status", components.WatchWant, "", components.WatchlistSortRecent, []int{3, 4}},
+		{"by kind", "", "movie", components.WatchlistSortRecent, []int{2, 3}},
+		{"by status and kind", components.WatchWant, "series", components.WatchlistSortRecent, []int{4}},
+		{"nothing matches", components.WatchWatched, "series", components.WatchlistSortRecent, []int{}},
+		{"added", "", "", components.WatchlistSortAdded, []int{3, 1, 4, 2}},
+		{"title ignoring case", "", "", components.WatchlistSortTitle, []int{2, 1, 4, 3}},
+		{"year", "", "", components.WatchlistSortYear, []int{1, 3, 4, 2}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			props := watchlistProps(items, tt.status, tt.kind, tt.order)
+			got := make([]int, len(props.Items))
+			for i, card := range props.Items {
+				got[i] = card.ID
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("items %v, want %v", got, tt.want)
+			}
+			if props.Total != len(items) {
+				t.Errorf("Total = %d, want %d", props.Total, len(items))
+			}
+		})
+	}
+}
+
+func TestMarkEpisodeWatched(t *testing.T) {
+	useAuthConfig(t, "")
+	store := useTestStore(t)
+	ctx := context.Background()
+	user, err := store.CreateUser(ctx, "alice", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := store.CreateSession(ctx, hashSessionToken("token"), user.ID, time.Now().Add(time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("unexpected TMDB request for %s", r.URL.Path)
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+	app := fiber.New()
+	setupAuth(app, "")
+	setupWatchlist(app, app.Group("/api"), client, "")
+
+	markWatched := func() int {
+		form := url.Values{"watched": {"true"}}
+		req := httptest.NewRequest(http.MethodPost, "/api/watchlist/series/1/season/1/episode/2", strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		req.AddCookie(&http.Cookie{Name: sessionCookieName, Value: "token"})
+		resp, err := app.Test(req, -1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp.StatusCode
+	}
+
+	if status := markWatched(); status != http.StatusConflict {
+		t.Errorf("episode of a series not on the watchlist: status %d, want 409", status)
+	}
+	if episodes, _ := store.WatchedEpisodes(ctx, user.ID, 1); len(episodes) != 0 {
+		t.Errorf("episode recorded despite the conflict: %+v", episodes)
+	}
+
+	if err := store.SetWatchStatus(ctx, user.ID, WatchlistItem{Kind: "series", MediaID: 1, Status: components.WatchWant, Title: "Andor"}); err != nil {
+		t.Fatal(err)
+	}
+	if status := markWatched(); status != http.StatusOK {
+		t.Fatalf("status %d, want 200", status)
+	}
+	if episodes, _ := store.WatchedEpisodes(ctx, user.ID, 1); len(episodes) != 1 || episodes[0].Episode != 2 {
+		t.Errorf("watched episodes = %+v, want S01E02", episodes)
+	}
+	if item, err := store.WatchlistItem(ctx, user.ID, "series", 1); err != nil || item.Status != components.WatchWatching {
+		t.Errorf("series after its first episode = %+v, %v; want it being watched", item, err)
+	}
+}